@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gilanghuda/backend-Quizzo/app/queries"
+	"github.com/gilanghuda/backend-Quizzo/pkg/database"
+	"github.com/gilanghuda/backend-Quizzo/pkg/middleware"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// RequestAccountDeletion schedules the caller's account for deletion after the grace period and signs it
+// out everywhere. Signing in again during the grace period and calling CancelAccountDeletion keeps the account.
+func RequestAccountDeletion(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	var req struct {
+		Password  string `json:"password"`
+		Anonymize bool   `json:"anonymize"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+
+	uq := queries.UserQueries{DB: database.DB}
+	hash, err := uq.GetPasswordHash(userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("GetPasswordHash error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to delete account"})
+	}
+	if hash != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(req.Password)); err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "wrong password"})
+		}
+	}
+
+	at := time.Now().Add(utils.AccountDeletionGracePeriod)
+	revoked, err := uq.ScheduleDeletion(userID, req.Anonymize, at)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("ScheduleDeletion error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to delete account"})
+	}
+	middleware.ForgetSessions(revoked...)
+	clearSessionCookies(c)
+	log.Info().Str("user_id", userID.String()).Time("deletion_scheduled_at", at).Bool("anonymize", req.Anonymize).Msg("account deletion scheduled")
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message":               "Account scheduled for deletion, sign in again before the date to cancel",
+		"deletion_scheduled_at": at,
+	})
+}
+
+func CancelAccountDeletion(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	uq := queries.UserQueries{DB: database.DB}
+	if err := uq.CancelDeletion(userID); err != nil {
+		if errors.Is(err, queries.ErrNoDeletionScheduled) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("CancelDeletion error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to cancel deletion"})
+	}
+	log.Info().Str("user_id", userID.String()).Msg("account deletion cancelled")
+	return c.JSON(fiber.Map{"message": "Account deletion cancelled"})
+}
+
+// ExportUserData sends the caller's profile, quizzes, attempts, comments, follows and group memberships
+// as a ZIP of JSON files
+func ExportUserData(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	uq := queries.UserQueries{DB: database.DB}
+	files, err := uq.ExportUserData(userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("ExportUserData error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to export data"})
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	now := time.Now()
+	for _, f := range files {
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, f.Data, "", "  "); err != nil {
+			log.Error().Err(err).Str("file", f.Name).Msg("failed to format export file")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to export data"})
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: f.Name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to export data"})
+		}
+		if _, err := w.Write(pretty.Bytes()); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to export data"})
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to export data"})
+	}
+
+	log.Info().Str("user_id", userID.String()).Int("bytes", buf.Len()).Msg("user data exported")
+	c.Set("Content-Type", "application/zip")
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"quizzo-export-%s.zip\"", now.Format("20060102")))
+	return c.Send(buf.Bytes())
+}
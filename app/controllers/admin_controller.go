@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/gilanghuda/backend-Quizzo/app/queries"
+	"github.com/gilanghuda/backend-Quizzo/pkg/database"
+	"github.com/gilanghuda/backend-Quizzo/pkg/middleware"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+func AdminListUsers(c *fiber.Ctx) error {
+	limit, offset := parseLimitOffset(c, 20)
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	f := models.AdminUserFilter{
+		Query:  strings.TrimSpace(c.Query("q")),
+		Role:   c.Query("role"),
+		Limit:  limit,
+		Offset: offset,
+	}
+	if f.Role != "" && !utils.IsValidRole(f.Role) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid role", "roles": utils.ValidRoles})
+	}
+	if s := c.Query("suspended"); s != "" {
+		v := c.QueryBool("suspended")
+		f.Suspended = &v
+	}
+
+	q := queries.AdminQueries{DB: database.DB}
+	users, err := q.ListUsers(f)
+	if err != nil {
+		log.Error().Err(err).Msg("ListUsers error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to list users"})
+	}
+	return c.JSON(fiber.Map{"users": users})
+}
+
+func AdminSetUserRole(c *fiber.Ctx) error {
+	adminID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user id"})
+	}
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
+	}
+	if !utils.IsValidRole(req.Role) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid role", "roles": utils.ValidRoles})
+	}
+
+	q := queries.AdminQueries{DB: database.DB}
+	if err := q.SetUserRole(userID, req.Role); err != nil {
+		switch {
+		case errors.Is(err, queries.ErrAdminUserNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, queries.ErrLastAdmin):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("SetUserRole error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to change role"})
+	}
+	log.Info().Str("admin_id", adminID.String()).Str("user_id", userID.String()).Str("role", req.Role).Msg("user role changed")
+	return c.JSON(fiber.Map{"user_id": userID, "role": req.Role})
+}
+
+func AdminSuspendUser(c *fiber.Ctx) error {
+	adminID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user id"})
+	}
+	if userID == adminID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "you cannot suspend yourself"})
+	}
+	var req struct {
+		Days *int `json:"days"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
+		}
+	}
+	var until *time.Time
+	if req.Days != nil {
+		if *req.Days <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "days must be positive"})
+		}
+		t := time.Now().AddDate(0, 0, *req.Days)
+		until = &t
+	}
+
+	q := queries.AdminQueries{DB: database.DB}
+	revoked, err := q.SuspendUser(userID, until)
+	if err != nil {
+		if errors.Is(err, queries.ErrAdminUserNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		if errors.Is(err, queries.ErrSuspendAdmin) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("SuspendUser error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to suspend user"})
+	}
+	middleware.ForgetSessions(revoked...)
+	log.Info().Str("admin_id", adminID.String()).Str("user_id", userID.String()).Msg("user suspended")
+	return c.JSON(fiber.Map{"user_id": userID, "suspended_until": until})
+}
+
+func AdminUnsuspendUser(c *fiber.Ctx) error {
+	adminID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user id"})
+	}
+
+	q := queries.AdminQueries{DB: database.DB}
+	if err := q.UnsuspendUser(userID); err != nil {
+		if errors.Is(err, queries.ErrAdminUserNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("UnsuspendUser error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to unsuspend user"})
+	}
+	log.Info().Str("admin_id", adminID.String()).Str("user_id", userID.String()).Msg("user unsuspended")
+	return c.JSON(fiber.Map{"message": "suspension lifted"})
+}
+
+func AdminDeleteContent(c *fiber.Ctx) error {
+	adminID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	targetType := c.Params("type")
+	if !utils.IsValidReportTarget(targetType) || targetType == utils.ReportTargetUser {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "type must be quiz, comment or study_group"})
+	}
+	targetID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid content id"})
+	}
+
+	q := queries.AdminQueries{DB: database.DB}
+	if err := q.DeleteContent(adminID, targetType, targetID); err != nil {
+		if errors.Is(err, queries.ErrContentNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Error().Err(err).Str("type", targetType).Str("id", targetID.String()).Msg("DeleteContent error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to delete content"})
+	}
+	log.Info().Str("admin_id", adminID.String()).Str("type", targetType).Str("id", targetID.String()).Msg("content deleted by admin")
+	return c.JSON(fiber.Map{"message": "content deleted"})
+}
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/gilanghuda/backend-Quizzo/app/queries"
+	"github.com/gilanghuda/backend-Quizzo/pkg/database"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// CreateAPIKey issues a personal API key. The key is returned only in this response.
+func CreateAPIKey(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	req := &models.CreateAPIKey{}
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if err := validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	scopes := make([]string, 0, len(req.Scopes))
+	seen := map[string]bool{}
+	for _, s := range req.Scopes {
+		if !utils.IsValidScope(s) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid scope: " + s, "scopes": utils.ValidScopes})
+		}
+		if !seen[s] {
+			seen[s] = true
+			scopes = append(scopes, s)
+		}
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays != nil {
+		t := time.Now().AddDate(0, 0, *req.ExpiresInDays)
+		expiresAt = &t
+	}
+
+	key, prefix, hash, err := utils.GenerateAPIKey()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to generate api key"})
+	}
+
+	q := queries.APIKeyQueries{DB: database.DB}
+	created, err := q.CreateAPIKey(userID, req.Name, prefix, hash, scopes, expiresAt)
+	if err != nil {
+		if errors.Is(err, queries.ErrTooManyAPIKeys) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("failed to create api key")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to create api key"})
+	}
+
+	log.Info().Str("user_id", userID.String()).Str("api_key_id", created.ID.String()).Strs("scopes", scopes).Msg("api key created")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"api_key": created, "key": key})
+}
+
+func GetAPIKeys(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	q := queries.APIKeyQueries{DB: database.DB}
+	keys, err := q.GetAPIKeys(userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("failed to get api keys")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get api keys"})
+	}
+	return c.JSON(fiber.Map{"api_keys": keys, "scopes": utils.ValidScopes})
+}
+
+func RevokeAPIKey(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	keyID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid api key id"})
+	}
+
+	q := queries.APIKeyQueries{DB: database.DB}
+	if err := q.RevokeAPIKey(userID, keyID); err != nil {
+		if errors.Is(err, queries.ErrAPIKeyNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("failed to revoke api key")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to revoke api key"})
+	}
+	log.Info().Str("user_id", userID.String()).Str("api_key_id", keyID.String()).Msg("api key revoked")
+	return c.JSON(fiber.Map{"message": "api key revoked"})
+}
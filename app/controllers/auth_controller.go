@@ -3,17 +3,17 @@ package controllers
 import (
 	"context"
 	"database/sql"
-	"os"
+	"errors"
 	"strings"
 	"time"
 
 	"github.com/gilanghuda/backend-Quizzo/app/models"
 	"github.com/gilanghuda/backend-Quizzo/app/queries"
 	"github.com/gilanghuda/backend-Quizzo/pkg/database"
+	"github.com/gilanghuda/backend-Quizzo/pkg/middleware"
 	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 	"golang.org/x/crypto/bcrypt"
@@ -28,6 +28,7 @@ func UserSignUp(c *fiber.Ctx) error {
 			"error": "Invalid request body",
 		})
 	}
+	signUp.Username = strings.TrimSpace(signUp.Username)
 
 	if err := validate.Struct(signUp); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -35,23 +36,19 @@ func UserSignUp(c *fiber.Ctx) error {
 		})
 	}
 
-	role := signUp.UserRole
-	if role == "" {
-		role = utils.RoleUser
+	if err := utils.ValidateUsername(signUp.Username); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
 
-	valid := false
-	for _, r := range utils.ValidRoles {
-		if role == r {
-			valid = true
-			break
-		}
-	}
-	if !valid {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+	// only plain user accounts can be self-registered; admins are promoted through the admin API
+	if signUp.UserRole != "" && signUp.UserRole != utils.RoleUser {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"error": "Invalid user role",
 		})
 	}
+	role := utils.RoleUser
 
 	userQueries := queries.UserQueries{DB: database.DB}
 	_, err := userQueries.GetUserByEmail(signUp.Email)
@@ -80,14 +77,23 @@ func UserSignUp(c *fiber.Ctx) error {
 	}
 
 	if err := userQueries.CreateUser(user); err != nil {
+		if errors.Is(err, queries.ErrUsernameTaken) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 		log.Error().Err(err).Msg("Error creating user")
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to create user",
 		})
 	}
 
+	if err := sendUserTokenEmail(user, utils.TokenPurposeEmailVerification); err != nil {
+		log.Error().Err(err).Str("user_id", user.ID.String()).Msg("failed to send verification email")
+	}
+
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"message": "User registered successfully",
+		"message": "User registered successfully, check your email to verify your address",
 	})
 }
 
@@ -119,39 +125,40 @@ func UserSignIn(c *fiber.Ctx) error {
 		})
 	}
 
-	secret := os.Getenv("JWT_SECRET")
-	claims := jwt.MapClaims{
-		"user_id":   user.ID.String(),
-		"email":     user.Email,
-		"user_role": user.UserRole,
-		"exp":       time.Now().Add(time.Hour * 72).Unix(),
+	if user.IsSuspended(time.Now()) {
+		return c.Status(fiber.StatusForbidden).JSON(suspendedResponse(&user))
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(secret))
+
+	if user.EmailVerifiedAt == nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "email not verified",
+		})
+	}
+
+	if handled, err := requireSecondFactor(c, &user); handled {
+		return err
+	}
+
+	tokens, err := startSession(c, &user)
 	if err != nil {
+		log.Error().Err(err).Msg("failed to start session")
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to generate token",
 		})
 	}
 
-	c.Cookie(&fiber.Cookie{
-		Name:     "token",
-		Value:    tokenString,
-		Expires:  time.Now().Add(time.Hour * 72),
-		Path:     "/",
-		HTTPOnly: false,
-		Secure:   false,
-		SameSite: "lax",
-	})
-
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Sign in successful",
 		"user": fiber.Map{
-			"id":        user.ID,
-			"email":     user.Email,
-			"user_role": user.UserRole,
+			"id":                    user.ID,
+			"email":                 user.Email,
+			"user_role":             user.UserRole,
+			"deletion_scheduled_at": user.DeletionScheduledAt,
 		},
-		"token": tokenString,
+		"token":                    tokens.AccessToken,
+		"token_expires_at":         tokens.AccessExpiresAt,
+		"refresh_token":            tokens.RefreshToken,
+		"refresh_token_expires_at": tokens.RefreshExpiresAt,
 	})
 }
 
@@ -189,7 +196,7 @@ func UserSignInGoogle(c *fiber.Ctx) error {
 				Email:        email,
 				PasswordHash: "",
 				ExpPoints:    "0",
-				UserRole:     "user",
+				UserRole:     utils.RoleUser,
 				ImageURL:     sql.NullString{Valid: false},
 				CreatedAt:    time.Now(),
 				UpdatedAt:    time.Now(),
@@ -205,38 +212,71 @@ func UserSignInGoogle(c *fiber.Ctx) error {
 		}
 	}
 
-	user.PasswordHash = ""
+	if user.IsSuspended(time.Now()) {
+		return c.Status(fiber.StatusForbidden).JSON(suspendedResponse(&user))
+	}
 
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "secret"
+	if user.EmailVerifiedAt == nil {
+		// Google only issues ID tokens for addresses it has verified
+		tq := queries.UserTokenQueries{DB: database.DB}
+		revoked, err := tq.MarkEmailVerified(user.ID)
+		if err != nil {
+			log.Error().Err(err).Str("user_id", user.ID.String()).Msg("failed to mark email verified")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to verify email"})
+		}
+		middleware.ForgetSessions(revoked...)
+		now := time.Now()
+		user.EmailVerifiedAt = &now
 	}
-	claims := jwt.MapClaims{
-		"user_id": user.ID.String(),
-		"exp":     time.Now().Add(72 * time.Hour).Unix(),
+
+	if handled, err := requireSecondFactor(c, &user); handled {
+		return err
 	}
-	tok := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenStr, err := tok.SignedString([]byte(secret))
+
+	user.PasswordHash = ""
+
+	tokens, err := startSession(c, &user)
 	if err != nil {
-		log.Error().Err(err).Msg("failed to sign token")
+		log.Error().Err(err).Msg("failed to start session")
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to create token"})
 	}
 
-	return c.JSON(fiber.Map{"token": tokenStr, "user": user})
+	return c.JSON(fiber.Map{
+		"token":                    tokens.AccessToken,
+		"token_expires_at":         tokens.AccessExpiresAt,
+		"refresh_token":            tokens.RefreshToken,
+		"refresh_token_expires_at": tokens.RefreshExpiresAt,
+		"user":                     user,
+	})
+}
+
+func suspendedResponse(user *models.User) fiber.Map {
+	res := fiber.Map{"error": "account is suspended"}
+	if user.SuspendedUntil != nil {
+		res["suspended_until"] = user.SuspendedUntil
+	}
+	return res
 }
 
+// UserLogout revokes the caller's session, identified by the access token when it is still valid
+// or by the refresh token otherwise, and clears the cookies
 func UserLogout(c *fiber.Ctx) error {
-	c.Cookie(&fiber.Cookie{
-		Name:     "token",
-		Value:    "",
-		Expires:  time.Now().Add(-time.Hour),
-		Domain:   "localhost",
-		Path:     "/",
-		HTTPOnly: true,
-		Secure:   false,
-		SameSite: "lax",
-		MaxAge:   -1,
-	})
+	sq := queries.SessionQueries{DB: database.DB}
+	if userID, err := utils.ExtractUserID(c); err == nil {
+		if sessionID, err := utils.ExtractSessionID(c); err == nil {
+			if err := sq.RevokeSession(sessionID, userID); err != nil && !errors.Is(err, queries.ErrSessionNotFound) {
+				log.Error().Err(err).Str("session_id", sessionID.String()).Msg("failed to revoke session on logout")
+			}
+			middleware.ForgetSessions(sessionID)
+		}
+	} else if token := refreshTokenFromRequest(c); token != "" {
+		if sessionID, err := sq.RevokeSessionByToken(utils.HashToken(token)); err == nil {
+			middleware.ForgetSessions(sessionID)
+		} else if !errors.Is(err, queries.ErrSessionNotFound) {
+			log.Error().Err(err).Msg("failed to revoke session on logout")
+		}
+	}
+	clearSessionCookies(c)
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Logout successful",
 	})
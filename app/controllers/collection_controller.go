@@ -0,0 +1,400 @@
+package controllers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/gilanghuda/backend-Quizzo/app/queries"
+	"github.com/gilanghuda/backend-Quizzo/pkg/database"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+func collectionError(c *fiber.Ctx, err error, msg string) error {
+	switch {
+	case errors.Is(err, queries.ErrCollectionNotFound), errors.Is(err, queries.ErrQuizUnavailable):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, queries.ErrInvalidOrder), errors.Is(err, queries.ErrEmptyCollection):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	log.Error().Err(err).Msg(msg)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": msg})
+}
+
+// canViewCollection allows the owner, anyone for public collections, and holders of the current share token
+func canViewCollection(col *models.Collection, userID uuid.UUID, token string) bool {
+	if col.OwnerID == userID || col.IsPublic {
+		return true
+	}
+	return token != "" && col.ShareToken != nil && *col.ShareToken == token
+}
+
+func AddBookmark(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	quizID, err := uuid.Parse(c.Params("quizId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid quiz id"})
+	}
+	q := queries.CollectionQueries{DB: database.DB}
+	if err := q.AddBookmark(userID, quizID); err != nil {
+		return collectionError(c, err, "failed to add bookmark")
+	}
+	log.Info().Str("user_id", userID.String()).Str("quiz_id", quizID.String()).Msg("quiz bookmarked")
+	return c.JSON(fiber.Map{"message": "bookmarked"})
+}
+
+func RemoveBookmark(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	quizID, err := uuid.Parse(c.Params("quizId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid quiz id"})
+	}
+	q := queries.CollectionQueries{DB: database.DB}
+	if err := q.RemoveBookmark(userID, quizID); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "bookmark removed"})
+}
+
+func GetBookmarks(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	limit, offset := parseLimitOffset(c, 20)
+	q := queries.CollectionQueries{DB: database.DB}
+	res, err := q.GetBookmarks(userID, limit, offset)
+	if err != nil {
+		return collectionError(c, err, "failed to get bookmarks")
+	}
+	return c.JSON(fiber.Map{"bookmarks": res})
+}
+
+type collectionRequest struct {
+	Name        string `json:"name" validate:"required,lte=100"`
+	Description string `json:"description" validate:"lte=1000"`
+	IsPublic    bool   `json:"is_public"`
+}
+
+func parseCollectionRequest(c *fiber.Ctx) (*collectionRequest, error) {
+	var req collectionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return nil, errors.New("invalid body")
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	req.Description = strings.TrimSpace(req.Description)
+	if err := validate.Struct(req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func CreateCollection(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	req, err := parseCollectionRequest(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	q := queries.CollectionQueries{DB: database.DB}
+	col, err := q.CreateCollection(&models.Collection{OwnerID: userID, Name: req.Name, Description: req.Description, IsPublic: req.IsPublic})
+	if err != nil {
+		return collectionError(c, err, "failed to create collection")
+	}
+	log.Info().Str("user_id", userID.String()).Str("collection_id", col.ID.String()).Msg("collection created")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"collection": col})
+}
+
+func UpdateCollection(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid collection id"})
+	}
+	req, err := parseCollectionRequest(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	q := queries.CollectionQueries{DB: database.DB}
+	if err := q.UpdateCollection(&models.Collection{ID: id, OwnerID: userID, Name: req.Name, Description: req.Description, IsPublic: req.IsPublic}); err != nil {
+		return collectionError(c, err, "failed to update collection")
+	}
+	return c.JSON(fiber.Map{"message": "collection updated"})
+}
+
+func DeleteCollection(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid collection id"})
+	}
+	q := queries.CollectionQueries{DB: database.DB}
+	if err := q.DeleteCollection(id, userID); err != nil {
+		return collectionError(c, err, "failed to delete collection")
+	}
+	log.Info().Str("user_id", userID.String()).Str("collection_id", id.String()).Msg("collection deleted")
+	return c.JSON(fiber.Map{"message": "collection deleted"})
+}
+
+func GetMyCollections(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	limit, offset := parseLimitOffset(c, 20)
+	q := queries.CollectionQueries{DB: database.DB}
+	res, err := q.GetCollectionsByOwner(userID, false, limit, offset)
+	if err != nil {
+		return collectionError(c, err, "failed to get collections")
+	}
+	return c.JSON(fiber.Map{"collections": res})
+}
+
+func GetUserCollections(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	ownerID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user id"})
+	}
+	limit, offset := parseLimitOffset(c, 20)
+	q := queries.CollectionQueries{DB: database.DB}
+	res, err := q.GetCollectionsByOwner(ownerID, ownerID != userID, limit, offset)
+	if err != nil {
+		return collectionError(c, err, "failed to get collections")
+	}
+	if ownerID != userID {
+		for i := range res {
+			res[i].ShareToken = nil
+		}
+	}
+	return c.JSON(fiber.Map{"collections": res})
+}
+
+func respondWithCollection(c *fiber.Ctx, col *models.Collection, userID uuid.UUID) error {
+	q := queries.CollectionQueries{DB: database.DB}
+	items, err := q.GetCollectionItems(col.ID, userID)
+	if err != nil {
+		return collectionError(c, err, "failed to get collection")
+	}
+	col.Items = items
+	if col.OwnerID != userID {
+		col.ShareToken = nil
+	}
+	return c.JSON(fiber.Map{"collection": col})
+}
+
+func GetCollection(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid collection id"})
+	}
+	q := queries.CollectionQueries{DB: database.DB}
+	col, err := q.GetCollection(id)
+	if err != nil {
+		return collectionError(c, err, "failed to get collection")
+	}
+	if col == nil || !canViewCollection(col, userID, c.Query("token")) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "collection not found"})
+	}
+	return respondWithCollection(c, col, userID)
+}
+
+func GetSharedCollection(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	q := queries.CollectionQueries{DB: database.DB}
+	col, err := q.GetCollectionByShareToken(c.Params("token"))
+	if err != nil {
+		return collectionError(c, err, "failed to get collection")
+	}
+	if col == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "collection not found"})
+	}
+	return respondWithCollection(c, col, userID)
+}
+
+func AddCollectionItem(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid collection id"})
+	}
+	var req struct {
+		QuizID string `json:"quiz_id"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
+	}
+	quizID, err := uuid.Parse(req.QuizID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid quiz_id"})
+	}
+	q := queries.CollectionQueries{DB: database.DB}
+	if err := q.AddCollectionItem(id, userID, quizID); err != nil {
+		return collectionError(c, err, "failed to add quiz to collection")
+	}
+	log.Info().Str("collection_id", id.String()).Str("quiz_id", quizID.String()).Msg("quiz added to collection")
+	return c.JSON(fiber.Map{"message": "quiz added to collection"})
+}
+
+func RemoveCollectionItem(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid collection id"})
+	}
+	quizID, err := uuid.Parse(c.Params("quizId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid quiz id"})
+	}
+	q := queries.CollectionQueries{DB: database.DB}
+	if err := q.RemoveCollectionItem(id, userID, quizID); err != nil {
+		if errors.Is(err, queries.ErrCollectionNotFound) {
+			return collectionError(c, err, "failed to remove quiz from collection")
+		}
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "quiz removed from collection"})
+}
+
+func ReorderCollection(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid collection id"})
+	}
+	var req struct {
+		QuizIDs []string `json:"quiz_ids"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
+	}
+	quizIDs := make([]uuid.UUID, 0, len(req.QuizIDs))
+	for _, s := range req.QuizIDs {
+		qid, err := uuid.Parse(s)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid quiz id in quiz_ids"})
+		}
+		quizIDs = append(quizIDs, qid)
+	}
+	q := queries.CollectionQueries{DB: database.DB}
+	if err := q.ReorderCollection(id, userID, quizIDs); err != nil {
+		return collectionError(c, err, "failed to reorder collection")
+	}
+	return c.JSON(fiber.Map{"message": "collection reordered"})
+}
+
+func ShareCollection(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid collection id"})
+	}
+	q := queries.CollectionQueries{DB: database.DB}
+	token, err := q.RotateShareToken(id, userID)
+	if err != nil {
+		return collectionError(c, err, "failed to share collection")
+	}
+	log.Info().Str("collection_id", id.String()).Msg("collection share token rotated")
+	return c.JSON(fiber.Map{"share_token": token})
+}
+
+func UnshareCollection(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid collection id"})
+	}
+	q := queries.CollectionQueries{DB: database.DB}
+	if err := q.RevokeShareToken(id, userID); err != nil {
+		return collectionError(c, err, "failed to unshare collection")
+	}
+	return c.JSON(fiber.Map{"message": "share link revoked"})
+}
+
+// StartCollection queues the collection's quizzes for the caller to attempt in order
+func StartCollection(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid collection id"})
+	}
+	q := queries.CollectionQueries{DB: database.DB}
+	col, err := q.GetCollection(id)
+	if err != nil {
+		return collectionError(c, err, "failed to start collection")
+	}
+	if col == nil || !canViewCollection(col, userID, c.Query("token")) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "collection not found"})
+	}
+	run, err := q.StartCollectionRun(id, userID)
+	if err != nil {
+		return collectionError(c, err, "failed to start collection")
+	}
+	log.Info().Str("user_id", userID.String()).Str("collection_id", id.String()).Str("run_id", run.ID.String()).Msg("collection run started")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"run": run})
+}
+
+func GetCollectionRun(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	runID, err := uuid.Parse(c.Params("runId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid run id"})
+	}
+	q := queries.CollectionQueries{DB: database.DB}
+	run, err := q.GetCollectionRun(runID, userID)
+	if err != nil {
+		return collectionError(c, err, "failed to get collection run")
+	}
+	if run == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "run not found"})
+	}
+	return c.JSON(fiber.Map{"run": run})
+}
@@ -0,0 +1,313 @@
+package controllers
+
+import (
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/gilanghuda/backend-Quizzo/app/queries"
+	"github.com/gilanghuda/backend-Quizzo/pkg/database"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// requireStudyGroupMember checks that userID belongs to the group in the :id param and returns the group id and their role
+func requireStudyGroupMember(c *fiber.Ctx, userID uuid.UUID) (uuid.UUID, string, *fiber.Error) {
+	groupID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return uuid.Nil, "", fiber.NewError(fiber.StatusBadRequest, "invalid group id")
+	}
+	sq := queries.StudyGroupQueries{DB: database.DB}
+	role, err := sq.GetMemberRole(groupID, userID)
+	if err != nil {
+		log.Error().Err(err).Str("study_group_id", groupID.String()).Str("user_id", userID.String()).Msg("failed to check membership")
+		return uuid.Nil, "", fiber.NewError(fiber.StatusInternalServerError, "failed to check membership")
+	}
+	if role == "" {
+		return uuid.Nil, "", fiber.NewError(fiber.StatusForbidden, "not a member of this study group")
+	}
+	return groupID, role, nil
+}
+
+func canModerateGroup(role string) bool {
+	return role == utils.GroupRoleOwner || role == utils.GroupRoleModerator
+}
+
+func GetStudyGroupThreads(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	groupID, _, ferr := requireStudyGroupMember(c, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	limit, offset := parseLimitOffset(c, 20)
+	announcementsOnly := c.QueryBool("announcements", false)
+
+	q := queries.DiscussionQueries{DB: database.DB}
+	threads, err := q.GetThreads(groupID, announcementsOnly, limit, offset)
+	if err != nil {
+		log.Error().Err(err).Str("study_group_id", groupID.String()).Msg("failed to get threads")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get threads"})
+	}
+	log.Info().Str("study_group_id", groupID.String()).Int("count", len(threads)).Msg("study group threads retrieved")
+	return c.JSON(fiber.Map{"threads": threads})
+}
+
+func CreateStudyGroupThread(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	groupID, role, ferr := requireStudyGroupMember(c, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	thread := &models.StudyGroupThread{}
+	if err := c.BodyParser(thread); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
+	}
+	if err := validate.Struct(thread); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if (thread.IsAnnouncement || thread.IsPinned) && !canModerateGroup(role) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "only owners and moderators can post announcements"})
+	}
+	thread.GroupID = groupID
+	thread.CreatedBy = userID
+	thread.QuizID = nil
+	if thread.IsAnnouncement {
+		thread.IsPinned = true
+	}
+
+	q := queries.DiscussionQueries{DB: database.DB}
+	id, err := q.CreateThread(thread)
+	if err != nil {
+		log.Error().Err(err).Str("study_group_id", groupID.String()).Msg("failed to create thread")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to create thread"})
+	}
+	log.Info().Str("study_group_id", groupID.String()).Str("thread_id", id.String()).Bool("announcement", thread.IsAnnouncement).Msg("study group thread created")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"thread_id": id})
+}
+
+func GetStudyGroupThreadPosts(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	groupID, _, ferr := requireStudyGroupMember(c, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+	threadID, err := uuid.Parse(c.Params("threadId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid thread id"})
+	}
+
+	q := queries.DiscussionQueries{DB: database.DB}
+	thread, err := q.GetThread(groupID, threadID)
+	if err != nil {
+		log.Error().Err(err).Str("thread_id", threadID.String()).Msg("failed to get thread")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get thread"})
+	}
+	if thread == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "thread not found"})
+	}
+
+	limit, offset := parseLimitOffset(c, 50)
+	posts, err := q.GetPosts(threadID, limit, offset)
+	if err != nil {
+		log.Error().Err(err).Str("thread_id", threadID.String()).Msg("failed to get posts")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get posts"})
+	}
+	log.Info().Str("thread_id", threadID.String()).Int("count", len(posts)).Msg("thread posts retrieved")
+	return c.JSON(fiber.Map{"thread": thread, "posts": posts})
+}
+
+func CreateStudyGroupPost(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	groupID, _, ferr := requireStudyGroupMember(c, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+	threadID, err := uuid.Parse(c.Params("threadId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid thread id"})
+	}
+
+	post := &models.StudyGroupPost{}
+	if err := c.BodyParser(post); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
+	}
+	if err := validate.Struct(post); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	q := queries.DiscussionQueries{DB: database.DB}
+	thread, err := q.GetThread(groupID, threadID)
+	if err != nil {
+		log.Error().Err(err).Str("thread_id", threadID.String()).Msg("failed to get thread")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get thread"})
+	}
+	if thread == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "thread not found"})
+	}
+
+	post.ThreadID = threadID
+	post.CreatedBy = userID
+	id, err := q.CreatePost(post)
+	if err != nil {
+		log.Error().Err(err).Str("thread_id", threadID.String()).Msg("failed to create post")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	log.Info().Str("thread_id", threadID.String()).Str("post_id", id.String()).Msg("thread post created")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"post_id": id})
+}
+
+func PinStudyGroupThread(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	groupID, role, ferr := requireStudyGroupMember(c, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+	if !canModerateGroup(role) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "forbidden"})
+	}
+	threadID, err := uuid.Parse(c.Params("threadId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid thread id"})
+	}
+
+	var req struct {
+		Pinned bool `json:"pinned"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
+	}
+
+	q := queries.DiscussionQueries{DB: database.DB}
+	if err := q.SetThreadPinned(groupID, threadID, req.Pinned); err != nil {
+		log.Error().Err(err).Str("thread_id", threadID.String()).Msg("failed to pin thread")
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	log.Info().Str("thread_id", threadID.String()).Bool("pinned", req.Pinned).Msg("thread pin updated")
+	return c.JSON(fiber.Map{"message": "updated"})
+}
+
+func DeleteStudyGroupThread(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	groupID, role, ferr := requireStudyGroupMember(c, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+	threadID, err := uuid.Parse(c.Params("threadId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid thread id"})
+	}
+
+	q := queries.DiscussionQueries{DB: database.DB}
+	thread, err := q.GetThread(groupID, threadID)
+	if err != nil {
+		log.Error().Err(err).Str("thread_id", threadID.String()).Msg("failed to get thread")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get thread"})
+	}
+	if thread == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "thread not found"})
+	}
+	if thread.CreatedBy != userID && !canModerateGroup(role) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "forbidden"})
+	}
+
+	if err := q.DeleteThread(groupID, threadID); err != nil {
+		log.Error().Err(err).Str("thread_id", threadID.String()).Msg("failed to delete thread")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to delete thread"})
+	}
+	log.Info().Str("thread_id", threadID.String()).Msg("thread deleted")
+	return c.JSON(fiber.Map{"message": "deleted"})
+}
+
+func GetQuizDiscussion(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	groupID, _, ferr := requireStudyGroupMember(c, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+	quizID, err := uuid.Parse(c.Params("quizId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid quiz id"})
+	}
+
+	qq := queries.QuizQueries{DB: database.DB}
+	title, err := qq.GetQuizTitleInStudyGroup(quizID, groupID)
+	if err != nil {
+		log.Error().Err(err).Str("quiz_id", quizID.String()).Msg("failed to check quiz assignment")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get quiz"})
+	}
+	if title == "" {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "quiz is not assigned to this study group"})
+	}
+
+	q := queries.DiscussionQueries{DB: database.DB}
+	thread, err := q.GetOrCreateQuizThread(groupID, quizID, userID, title)
+	if err != nil {
+		log.Error().Err(err).Str("quiz_id", quizID.String()).Msg("failed to get quiz thread")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get quiz discussion"})
+	}
+
+	limit, offset := parseLimitOffset(c, 50)
+	posts, err := q.GetPosts(thread.ID, limit, offset)
+	if err != nil {
+		log.Error().Err(err).Str("thread_id", thread.ID.String()).Msg("failed to get posts")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get posts"})
+	}
+	log.Info().Str("quiz_id", quizID.String()).Str("thread_id", thread.ID.String()).Msg("quiz discussion retrieved")
+	return c.JSON(fiber.Map{"thread": thread, "posts": posts})
+}
+
+func SetStudyGroupMemberRole(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	memberID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user id"})
+	}
+
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
+	}
+	if req.Role != utils.GroupRoleModerator && req.Role != utils.GroupRoleMember {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "role must be moderator or member"})
+	}
+
+	q := queries.StudyGroupQueries{DB: database.DB}
+	sg, ferr := getOwnedStudyGroup(c, &q, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	if err := q.SetMemberRole(sg.ID, memberID, req.Role); err != nil {
+		log.Error().Err(err).Str("study_group_id", sg.ID.String()).Str("member_id", memberID.String()).Msg("failed to set member role")
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	log.Info().Str("study_group_id", sg.ID.String()).Str("member_id", memberID.String()).Str("role", req.Role).Msg("member role updated")
+	return c.JSON(fiber.Map{"message": "updated"})
+}
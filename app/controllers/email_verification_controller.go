@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/gilanghuda/backend-Quizzo/app/queries"
+	"github.com/gilanghuda/backend-Quizzo/pkg/database"
+	"github.com/gilanghuda/backend-Quizzo/pkg/mailer"
+	"github.com/gilanghuda/backend-Quizzo/pkg/middleware"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// userTokenEmails maps a token purpose to the template it is mailed with and the frontend page that consumes it
+var userTokenEmails = map[string]struct {
+	template string
+	path     string
+	ttl      time.Duration
+}{
+	utils.TokenPurposeEmailVerification: {mailer.TemplateVerifyEmail, "/verify-email", utils.EmailVerificationTTL},
+	utils.TokenPurposePasswordReset:     {mailer.TemplatePasswordReset, "/reset-password", utils.PasswordResetTTL},
+}
+
+// sendUserTokenEmail issues a single-use token for purpose and mails the link to the user.
+// Delivery happens in the background; failures are logged.
+func sendUserTokenEmail(user *models.User, purpose string) error {
+	cfg := userTokenEmails[purpose]
+	token, err := utils.GenerateInviteCode(48)
+	if err != nil {
+		return err
+	}
+	tq := queries.UserTokenQueries{DB: database.DB}
+	if err := tq.CreateUserToken(user.ID, purpose, utils.HashToken(token), time.Now().Add(cfg.ttl)); err != nil {
+		return err
+	}
+
+	msg, err := mailer.Render(cfg.template, user.Email, map[string]string{
+		"Username":  user.Username,
+		"Link":      mailer.AppURL() + cfg.path + "?token=" + url.QueryEscape(token),
+		"ExpiresIn": cfg.ttl.String(),
+	})
+	if err != nil {
+		return err
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := mailer.Default.Send(ctx, msg); err != nil {
+			log.Error().Err(err).Str("user_id", user.ID.String()).Str("purpose", purpose).Msg("failed to send email")
+		}
+	}()
+	return nil
+}
+
+func VerifyEmail(c *fiber.Ctx) error {
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "token is required"})
+	}
+
+	tq := queries.UserTokenQueries{DB: database.DB}
+	userID, err := tq.VerifyEmail(utils.HashToken(req.Token))
+	if err != nil {
+		if errors.Is(err, queries.ErrInvalidUserToken) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Error().Err(err).Msg("VerifyEmail error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to verify email"})
+	}
+	log.Info().Str("user_id", userID.String()).Msg("email verified")
+	return c.JSON(fiber.Map{"message": "Email verified"})
+}
+
+// ResendVerificationEmail always answers the same way so it cannot be used to find registered addresses
+func ResendVerificationEmail(c *fiber.Ctx) error {
+	req := &models.EmailRequest{}
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if err := validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	uq := queries.UserQueries{DB: database.DB}
+	if user, err := uq.GetUserByEmail(req.Email); err == nil && user.EmailVerifiedAt == nil {
+		if err := sendUserTokenEmail(&user, utils.TokenPurposeEmailVerification); err != nil && !errors.Is(err, queries.ErrUserTokenTooSoon) {
+			log.Error().Err(err).Str("user_id", user.ID.String()).Msg("failed to resend verification email")
+		}
+	}
+	return c.JSON(fiber.Map{"message": "If the address needs verification, an email is on its way"})
+}
+
+// ForgotPassword always answers the same way so it cannot be used to find registered addresses
+func ForgotPassword(c *fiber.Ctx) error {
+	req := &models.EmailRequest{}
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if err := validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	uq := queries.UserQueries{DB: database.DB}
+	if user, err := uq.GetUserByEmail(req.Email); err == nil {
+		if err := sendUserTokenEmail(&user, utils.TokenPurposePasswordReset); err != nil && !errors.Is(err, queries.ErrUserTokenTooSoon) {
+			log.Error().Err(err).Str("user_id", user.ID.String()).Msg("failed to send password reset email")
+		}
+	}
+	return c.JSON(fiber.Map{"message": "If the address is registered, a reset link is on its way"})
+}
+
+func ResetPassword(c *fiber.Ctx) error {
+	req := &models.ResetPassword{}
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if err := validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to hash password"})
+	}
+
+	tq := queries.UserTokenQueries{DB: database.DB}
+	userID, revoked, err := tq.ResetPassword(utils.HashToken(req.Token), string(hashed))
+	if err != nil {
+		if errors.Is(err, queries.ErrInvalidUserToken) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Error().Err(err).Msg("ResetPassword error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to reset password"})
+	}
+	middleware.ForgetSessions(revoked...)
+	clearSessionCookies(c)
+	log.Info().Str("user_id", userID.String()).Int("sessions_revoked", len(revoked)).Msg("password reset")
+	return c.JSON(fiber.Map{"message": "Password has been reset, please sign in again"})
+}
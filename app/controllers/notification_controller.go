@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"github.com/gilanghuda/backend-Quizzo/app/queries"
+	"github.com/gilanghuda/backend-Quizzo/pkg/database"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// emitNotification records a notification for recipient. Failures are logged and never fail the calling request.
+func emitNotification(recipient, actor uuid.UUID, typ string, entityID *uuid.UUID, data map[string]interface{}) {
+	if recipient == actor {
+		return
+	}
+	q := queries.NotificationQueries{DB: database.DB}
+	if _, err := q.CreateNotification(recipient, actor, typ, entityID, data); err != nil {
+		log.Error().Err(err).Str("user_id", recipient.String()).Str("type", typ).Msg("failed to create notification")
+	}
+}
+
+// emitStudyGroupNotification notifies every member of the group except the actor
+func emitStudyGroupNotification(groupID, actor uuid.UUID, typ string, entityID *uuid.UUID, data map[string]interface{}) {
+	q := queries.NotificationQueries{DB: database.DB}
+	if _, err := q.NotifyStudyGroupMembers(groupID, actor, typ, entityID, data); err != nil {
+		log.Error().Err(err).Str("study_group_id", groupID.String()).Str("type", typ).Msg("failed to create study group notifications")
+	}
+}
+
+func GetNotifications(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	limit, offset := parseLimitOffset(c, 20)
+	unreadOnly := c.QueryBool("unread_only", false)
+
+	q := queries.NotificationQueries{DB: database.DB}
+	res, err := q.GetNotifications(userID, unreadOnly, limit, offset)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("failed to get notifications")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get notifications"})
+	}
+	unread, err := q.CountUnread(userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("failed to count unread notifications")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get notifications"})
+	}
+	log.Info().Str("user_id", userID.String()).Int("count", len(res)).Msg("notifications retrieved")
+	return c.JSON(fiber.Map{"notifications": res, "unread_count": unread})
+}
+
+func GetUnreadNotificationCount(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	q := queries.NotificationQueries{DB: database.DB}
+	unread, err := q.CountUnread(userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("failed to count unread notifications")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to count notifications"})
+	}
+	return c.JSON(fiber.Map{"unread_count": unread})
+}
+
+func MarkNotificationRead(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid notification id"})
+	}
+	q := queries.NotificationQueries{DB: database.DB}
+	if err := q.MarkRead(userID, id); err != nil {
+		log.Error().Err(err).Str("notification_id", id.String()).Msg("failed to mark notification read")
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"message": "marked as read"})
+}
+
+func MarkAllNotificationsRead(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	q := queries.NotificationQueries{DB: database.DB}
+	n, err := q.MarkAllRead(userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("failed to mark notifications read")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to mark notifications read"})
+	}
+	log.Info().Str("user_id", userID.String()).Int64("count", n).Msg("notifications marked read")
+	return c.JSON(fiber.Map{"message": "marked as read", "updated": n})
+}
+
+func GetNotificationPreferences(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	q := queries.NotificationQueries{DB: database.DB}
+	prefs, err := q.GetPreferences(userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("failed to get notification preferences")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get preferences"})
+	}
+	return c.JSON(fiber.Map{"preferences": prefs})
+}
+
+func UpdateNotificationPreferences(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	var req map[string]bool
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
+	}
+	for typ := range req {
+		if !utils.IsValidNotificationType(typ) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "unknown notification type: " + typ})
+		}
+	}
+
+	q := queries.NotificationQueries{DB: database.DB}
+	for typ, enabled := range req {
+		if err := q.SetPreference(userID, typ, enabled); err != nil {
+			log.Error().Err(err).Str("user_id", userID.String()).Str("type", typ).Msg("failed to set notification preference")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to update preferences"})
+		}
+	}
+	prefs, err := q.GetPreferences(userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("failed to get notification preferences")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get preferences"})
+	}
+	log.Info().Str("user_id", userID.String()).Msg("notification preferences updated")
+	return c.JSON(fiber.Map{"preferences": prefs})
+}
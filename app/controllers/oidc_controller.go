@@ -0,0 +1,335 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/gilanghuda/backend-Quizzo/app/queries"
+	"github.com/gilanghuda/backend-Quizzo/pkg/database"
+	"github.com/gilanghuda/backend-Quizzo/pkg/middleware"
+	"github.com/gilanghuda/backend-Quizzo/pkg/oidc"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+func GetOIDCProviders(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{"providers": oidc.Default.Names()})
+}
+
+const oidcStateCookie = "oidc_state"
+
+// beginOIDC creates the state, nonce and PKCE verifier for a new authorization request and
+// responds with the provider's authorization URL, or redirects to it when ?redirect=true
+func beginOIDC(c *fiber.Ctx, linkUserID *uuid.UUID) error {
+	provider, err := oidc.Default.Get(c.Params("provider"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	state, err := oidc.RandomString(32)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to start login"})
+	}
+	nonce, err := oidc.RandomString(32)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to start login"})
+	}
+	verifier, err := oidc.RandomString(48)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to start login"})
+	}
+
+	authURL, err := provider.AuthCodeURL(c.UserContext(), state, nonce, verifier)
+	if err != nil {
+		log.Error().Err(err).Str("provider", provider.Name).Msg("oidc discovery failed")
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": "identity provider is unavailable"})
+	}
+
+	iq := queries.IdentityQueries{DB: database.DB}
+	loginState := &models.OIDCLoginState{Provider: provider.Name, CodeVerifier: verifier, Nonce: nonce, LinkUserID: linkUserID}
+	if err := iq.CreateLoginState(utils.HashToken(state), loginState, time.Now().Add(utils.OIDCLoginStateTTL)); err != nil {
+		log.Error().Err(err).Msg("failed to store oidc login state")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to start login"})
+	}
+
+	// the callback only accepts the state from the browser that started the flow (login CSRF)
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Expires:  time.Now().Add(utils.OIDCLoginStateTTL),
+		Path:     "/auth/oidc",
+		HTTPOnly: true,
+		Secure:   false,
+		SameSite: "lax",
+	})
+
+	if c.QueryBool("redirect") {
+		return c.Redirect(authURL, fiber.StatusFound)
+	}
+	return c.JSON(fiber.Map{"authorization_url": authURL})
+}
+
+func StartOIDCLogin(c *fiber.Ctx) error {
+	return beginOIDC(c, nil)
+}
+
+// LinkOIDCIdentity starts an authorization request whose callback links the provider account to the caller
+func LinkOIDCIdentity(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	return beginOIDC(c, &userID)
+}
+
+// OIDCCallback completes the authorization request. It accepts code and state either as query
+// parameters (the provider redirecting here) or in a JSON body (a frontend forwarding them).
+func OIDCCallback(c *fiber.Ctx) error {
+	var req struct {
+		Code  string `json:"code"`
+		State string `json:"state"`
+	}
+	if c.Method() == fiber.MethodPost {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+		}
+	} else {
+		if e := c.Query("error"); e != "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": e, "error_description": c.Query("error_description")})
+		}
+		req.Code, req.State = c.Query("code"), c.Query("state")
+	}
+	if req.Code == "" || req.State == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "code and state are required"})
+	}
+
+	provider, err := oidc.Default.Get(c.Params("provider"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	bound := c.Cookies(oidcStateCookie)
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcStateCookie,
+		Value:    "",
+		Expires:  time.Unix(0, 0),
+		Path:     "/auth/oidc",
+		HTTPOnly: true,
+		SameSite: "lax",
+	})
+	if bound == "" || subtle.ConstantTimeCompare([]byte(bound), []byte(req.State)) != 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": queries.ErrInvalidLoginState.Error()})
+	}
+
+	iq := queries.IdentityQueries{DB: database.DB}
+	loginState, err := iq.ConsumeLoginState(utils.HashToken(req.State))
+	if err != nil {
+		if errors.Is(err, queries.ErrInvalidLoginState) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Error().Err(err).Msg("failed to load oidc login state")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to complete login"})
+	}
+	if loginState.Provider != provider.Name {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": queries.ErrInvalidLoginState.Error()})
+	}
+
+	identity, err := provider.Exchange(c.UserContext(), req.Code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		log.Error().Err(err).Str("provider", provider.Name).Msg("oidc code exchange failed")
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "sign in with the identity provider failed"})
+	}
+
+	if loginState.LinkUserID != nil {
+		// linking must be finished by the same signed-in user who started it, otherwise an attacker could
+		// hand their link URL to a victim and collect the victim's provider account
+		userID, err := utils.ExtractUserID(c)
+		if err != nil || userID != *loginState.LinkUserID {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "sign in to the account that started linking"})
+		}
+		return linkIdentity(c, *loginState.LinkUserID, provider.Name, identity)
+	}
+	return signInWithIdentity(c, provider.Name, identity)
+}
+
+func linkIdentity(c *fiber.Ctx, userID uuid.UUID, provider string, identity *oidc.Identity) error {
+	iq := queries.IdentityQueries{DB: database.DB}
+	if err := iq.LinkIdentity(userID, provider, identity.Subject, identity.Email); err != nil {
+		switch {
+		case errors.Is(err, queries.ErrIdentityLinkedElsewhere), errors.Is(err, queries.ErrProviderAlreadyLinked):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		default:
+			log.Error().Err(err).Str("user_id", userID.String()).Msg("failed to link identity")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to link identity"})
+		}
+	}
+	return c.JSON(fiber.Map{"message": "identity linked", "provider": provider})
+}
+
+// signInWithIdentity finds the user linked to the provider account. An unlinked account is linked to the
+// user with the same email, or a new user is created, but only when the provider vouches for the address.
+func signInWithIdentity(c *fiber.Ctx, provider string, identity *oidc.Identity) error {
+	iq := queries.IdentityQueries{DB: database.DB}
+	uq := queries.UserQueries{DB: database.DB}
+
+	email, err := iq.GetIdentityUserEmail(provider, identity.Subject, identity.Email)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to look up identity")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get user"})
+	}
+
+	var user models.User
+	if email != "" {
+		user, err = uq.GetUserByEmail(email)
+		if err != nil {
+			log.Error().Err(err).Msg("GetUserByEmail error")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get user"})
+		}
+	} else {
+		if identity.Email == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "identity provider did not share an email address"})
+		}
+		user, err = uq.GetUserByEmail(identity.Email)
+		switch {
+		case err == nil:
+			if !identity.EmailVerified {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error": "an account with this email already exists; sign in and link the provider from your settings",
+				})
+			}
+		case err.Error() == "user not found":
+			// an unverified claim must not reserve someone else's address or skip email verification
+			if !identity.EmailVerified {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error": "the identity provider has not verified this email address; sign up with a password instead",
+				})
+			}
+			user, err = createIdentityUser(&uq, identity)
+			if err != nil {
+				log.Error().Err(err).Msg("failed to create user")
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to create user"})
+			}
+		default:
+			log.Error().Err(err).Msg("GetUserByEmail error")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get user"})
+		}
+		// both branches above require a provider-verified address matching the account
+		revoked, err := iq.LinkIdentityAndVerify(user.ID, provider, identity.Subject, identity.Email)
+		if err != nil {
+			if errors.Is(err, queries.ErrProviderAlreadyLinked) {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+			}
+			log.Error().Err(err).Str("user_id", user.ID.String()).Msg("failed to link identity")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to link identity"})
+		}
+		middleware.ForgetSessions(revoked...)
+		if user.EmailVerifiedAt == nil {
+			now := time.Now()
+			user.EmailVerifiedAt = &now
+		}
+	}
+
+	if user.IsSuspended(time.Now()) {
+		return c.Status(fiber.StatusForbidden).JSON(suspendedResponse(&user))
+	}
+
+	if user.EmailVerifiedAt == nil && identity.EmailVerified && strings.EqualFold(identity.Email, user.Email) {
+		tq := queries.UserTokenQueries{DB: database.DB}
+		revoked, err := tq.MarkEmailVerified(user.ID)
+		if err != nil {
+			log.Error().Err(err).Str("user_id", user.ID.String()).Msg("failed to mark email verified")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to verify email"})
+		}
+		middleware.ForgetSessions(revoked...)
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+
+	if handled, err := requireSecondFactor(c, &user); handled {
+		return err
+	}
+
+	user.PasswordHash = ""
+
+	tokens, err := startSession(c, &user)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to start session")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to create token"})
+	}
+
+	return c.JSON(fiber.Map{
+		"token":                    tokens.AccessToken,
+		"token_expires_at":         tokens.AccessExpiresAt,
+		"refresh_token":            tokens.RefreshToken,
+		"refresh_token_expires_at": tokens.RefreshExpiresAt,
+		"user":                     user,
+	})
+}
+
+func createIdentityUser(uq *queries.UserQueries, identity *oidc.Identity) (models.User, error) {
+	base := identity.Username
+	if base == "" || strings.Contains(base, "@") {
+		base = strings.Split(identity.Email, "@")[0]
+	}
+	username, err := uq.AvailableUsername(base)
+	if err != nil {
+		return models.User{}, err
+	}
+	user := models.User{
+		ID:           uuid.New(),
+		Username:     username,
+		Email:        identity.Email,
+		PasswordHash: "",
+		ExpPoints:    "0",
+		UserRole:     utils.RoleUser,
+		ImageURL:     sql.NullString{Valid: false},
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+	if err := uq.CreateUser(&user); err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+func GetIdentities(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	iq := queries.IdentityQueries{DB: database.DB}
+	identities, err := iq.GetIdentities(userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("failed to get identities")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get identities"})
+	}
+	return c.JSON(fiber.Map{"identities": identities})
+}
+
+func UnlinkIdentity(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	iq := queries.IdentityQueries{DB: database.DB}
+	if err := iq.UnlinkIdentity(userID, c.Params("provider")); err != nil {
+		switch {
+		case errors.Is(err, queries.ErrIdentityNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, queries.ErrLastLoginMethod):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		default:
+			log.Error().Err(err).Str("user_id", userID.String()).Msg("failed to unlink identity")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to unlink identity"})
+		}
+	}
+	return c.JSON(fiber.Map{"message": "identity unlinked"})
+}
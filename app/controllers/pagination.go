@@ -0,0 +1,24 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// parseLimitOffset reads the limit and offset query params, falling back to defaultLimit and 0
+func parseLimitOffset(c *fiber.Ctx, defaultLimit int) (int, int) {
+	limit := defaultLimit
+	offset := 0
+	if l := c.Query("limit"); l != "" {
+		if v, err := strconv.Atoi(l); err == nil {
+			limit = v
+		}
+	}
+	if o := c.Query("offset"); o != "" {
+		if v, err := strconv.Atoi(o); err == nil {
+			offset = v
+		}
+	}
+	return limit, offset
+}
@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/gilanghuda/backend-Quizzo/app/queries"
+	"github.com/gilanghuda/backend-Quizzo/pkg/database"
+	"github.com/gilanghuda/backend-Quizzo/pkg/middleware"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func UpdateProfile(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	req := &models.UpdateProfile{}
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if req.Username != nil {
+		name := strings.TrimSpace(*req.Username)
+		req.Username = &name
+	}
+	if err := validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if req.Username != nil {
+		if err := utils.ValidateUsername(*req.Username); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+	if req.Username == nil && req.Bio == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "nothing to update"})
+	}
+
+	uq := queries.UserQueries{DB: database.DB}
+	if err := uq.UpdateProfile(userID, req.Username, req.Bio); err != nil {
+		if errors.Is(err, queries.ErrUsernameTaken) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("UpdateProfile error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to update profile"})
+	}
+
+	user, err := uq.GetUserByID(userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("GetUserByID error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get user"})
+	}
+	user.PasswordHash = ""
+	log.Info().Str("user_id", userID.String()).Msg("profile updated")
+	return c.JSON(user)
+}
+
+// ChangePassword requires the current password, except for accounts that never had one (Google sign-in).
+// Every other session is signed out.
+func ChangePassword(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	req := &models.ChangePassword{}
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if err := validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	uq := queries.UserQueries{DB: database.DB}
+	current, err := uq.GetPasswordHash(userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("GetPasswordHash error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to change password"})
+	}
+	if current != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(current), []byte(req.CurrentPassword)); err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "wrong password"})
+		}
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to hash password"})
+	}
+	keep, _ := utils.ExtractSessionID(c)
+	revoked, err := uq.UpdatePassword(userID, string(hashed), keep)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("UpdatePassword error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to change password"})
+	}
+	middleware.ForgetSessions(revoked...)
+	log.Info().Str("user_id", userID.String()).Int("sessions_revoked", len(revoked)).Msg("password changed")
+	return c.JSON(fiber.Map{"message": "Password changed", "sessions_revoked": len(revoked)})
+}
+
+// UploadAvatar takes an image in the "avatar" form field, stores a square JPEG in every utils.AvatarSizes
+// through the file storage and points the user's image_url at the new avatar
+func UploadAvatar(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	fileHeader, err := c.FormFile("avatar")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "avatar file is required"})
+	}
+	if fileHeader.Size > utils.MaxAvatarBytes {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "avatar is too large"})
+	}
+	f, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "failed to read avatar"})
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, utils.MaxAvatarBytes+1))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "failed to read avatar"})
+	}
+
+	img, err := utils.DecodeAvatar(data)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// scale the largest size from the original and each smaller one from the previous result
+	key := uuid.NewString()
+	src := img
+	for i := len(utils.AvatarSizes) - 1; i >= 0; i-- {
+		size := utils.AvatarSizes[i]
+		resized := utils.ResizeSquare(src, size)
+		out, err := utils.EncodeJPEG(resized)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to encode avatar")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to process avatar"})
+		}
+		fileID := utils.AvatarFileID(key, size)
+		if err := utils.SaveFileWithType(fileID, fileID+".jpg", "image/jpeg", out); err != nil {
+			log.Error().Err(err).Str("file_id", fileID).Msg("failed to store avatar")
+			return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": "failed to store avatar"})
+		}
+		src = resized
+	}
+
+	imageURL := "/avatars/" + key
+	uq := queries.UserQueries{DB: database.DB}
+	if err := uq.SetImageURL(userID, &imageURL); err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("SetImageURL error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to update avatar"})
+	}
+	log.Info().Str("user_id", userID.String()).Str("avatar", key).Msg("avatar updated")
+
+	sizes := fiber.Map{}
+	for _, size := range utils.AvatarSizes {
+		sizes[strconv.Itoa(size)] = imageURL + "?size=" + strconv.Itoa(size)
+	}
+	return c.JSON(fiber.Map{"image_url": imageURL, "sizes": sizes})
+}
+
+func DeleteAvatar(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	uq := queries.UserQueries{DB: database.DB}
+	if err := uq.SetImageURL(userID, nil); err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("SetImageURL error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to remove avatar"})
+	}
+	return c.JSON(fiber.Map{"message": "avatar removed"})
+}
+
+// GetAvatar serves one size of a stored avatar; ?size defaults to the largest
+func GetAvatar(c *fiber.Ctx) error {
+	key, err := uuid.Parse(c.Params("key"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid avatar id"})
+	}
+	size := c.QueryInt("size", utils.AvatarSizes[len(utils.AvatarSizes)-1])
+	valid := false
+	for _, s := range utils.AvatarSizes {
+		if s == size {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "unsupported size", "sizes": utils.AvatarSizes})
+	}
+
+	data, contentType, err := utils.GetFile(utils.AvatarFileID(key.String(), size))
+	if err != nil {
+		log.Error().Err(err).Str("avatar", key.String()).Msg("GetFile error")
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "avatar not found"})
+	}
+	if contentType == "" {
+		contentType = "image/jpeg"
+	}
+	c.Set("Content-Type", contentType)
+	c.Set("Cache-Control", "public, max-age=31536000, immutable")
+	return c.Send(data)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
 
 	"github.com/gilanghuda/backend-Quizzo/app/models"
 	"github.com/gilanghuda/backend-Quizzo/app/queries"
@@ -197,15 +198,37 @@ func GetFeed(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	mode := c.Query("mode", models.FeedModeLatest)
+	if mode != models.FeedModeLatest && mode != models.FeedModeRanked {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "mode must be latest or ranked"})
+	}
+
+	tag := strings.ToLower(strings.TrimSpace(c.Query("tag")))
+
+	var cursor *models.FeedCursor
+	if cs := c.Query("cursor"); cs != "" {
+		cursor = &models.FeedCursor{}
+		if err := utils.DecodeCursor(cs, cursor); err != nil || cursor.Mode != mode || cursor.Tag != tag {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid cursor"})
+		}
+	}
+
+	limit := 10
+	if l := c.Query("limit"); l != "" {
+		if v, err := strconv.Atoi(l); err == nil {
+			limit = v
+		}
+	}
+
 	q := queries.QuizQueries{DB: database.DB}
-	feed, err := q.GetFeedWithLikes(userID.String())
+	page, err := q.GetFeedWithLikes(userID.String(), mode, tag, cursor, limit)
 	if err != nil {
 		log.Error().Err(err).Msg("GetFeedWithLikes error")
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get feed"})
 	}
-	log.Info().Str("user_id", userID.String()).Int("feed_count", len(feed)).Msg("feed retrieved")
+	log.Info().Str("user_id", userID.String()).Str("mode", mode).Int("feed_count", len(page.Items)).Msg("feed retrieved")
 
-	return c.JSON(fiber.Map{"feed": feed})
+	return c.JSON(page)
 }
 
 func AttemptQuiz(c *fiber.Ctx) error {
@@ -227,14 +250,32 @@ func AttemptQuiz(c *fiber.Ctx) error {
 	}
 
 	var req struct {
-		QuizID  string            `json:"quiz_id"`
-		Answers map[string]string `json:"answers"`
+		QuizID     string            `json:"quiz_id"`
+		ShareToken string            `json:"share_token"`
+		Answers    map[string]string `json:"answers"`
 	}
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
 	}
+	quizID, err := uuid.Parse(req.QuizID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid quiz id"})
+	}
+	viewer, err := uuid.Parse(userID)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user id in token"})
+	}
 
 	q := queries.QuizQueries{DB: database.DB}
+	allowed, err := q.CanViewQuiz(quizID, viewer, req.ShareToken)
+	if err != nil {
+		log.Error().Err(err).Msg("CanViewQuiz error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to check quiz"})
+	}
+	if !allowed {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "quiz not found"})
+	}
+
 	attempted, err := q.HasUserAttemptedQuiz(req.QuizID, userID)
 	if err != nil {
 		log.Error().Err(err).Msg("HasUserAttemptedQuiz error")
@@ -299,16 +340,37 @@ func GetQuizDetail(c *fiber.Ctx) error {
 	if id == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "quiz id is required"})
 	}
+	quizID, err := uuid.Parse(id)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid quiz id"})
+	}
+	viewer, err := utils.ExtractUserID(c)
+	if err != nil {
+		viewer = uuid.Nil
+	}
+
 	q := queries.QuizQueries{DB: database.DB}
+	allowed, err := q.CanViewQuiz(quizID, viewer, c.Query("share_token"))
+	if err != nil {
+		log.Error().Err(err).Msg("CanViewQuiz error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get quiz"})
+	}
+	if !allowed {
+		log.Info().Str("quiz_id", id).Msg("quiz not found")
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "quiz not found"})
+	}
 	quiz, err := q.GetQuizByID(id)
 	if err != nil {
 		log.Error().Err(err).Msg("GetQuizByID error")
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get quiz"})
 	}
-	if quiz == nil {
+	if quiz == nil || quiz.IsHidden {
 		log.Info().Str("quiz_id", id).Msg("quiz not found")
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "quiz not found"})
 	}
+	if quiz.CreatedBy != viewer.String() {
+		quiz.ShareToken = nil
+	}
 	log.Info().Str("quiz_id", id).Msg("quiz detail retrieved")
 	return c.JSON(fiber.Map{"quiz": quiz})
 }
@@ -321,7 +383,7 @@ func GetUserLeaderboard(c *fiber.Ctx) error {
 		}
 	}
 	q := queries.QuizQueries{DB: database.DB}
-	res, err := q.GetUserLeaderboard(limit)
+	res, err := q.GetUserLeaderboard(limit, strings.ToLower(strings.TrimSpace(c.Query("tag"))))
 	if err != nil {
 		log.Error().Err(err).Msg("GetUserLeaderboard error")
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get leaderboard"})
@@ -337,7 +399,7 @@ func GetStudyGroupLeaderboard(c *fiber.Ctx) error {
 		}
 	}
 	q := queries.QuizQueries{DB: database.DB}
-	res, err := q.GetStudyGroupLeaderboard(limit)
+	res, err := q.GetStudyGroupLeaderboard(limit, strings.ToLower(strings.TrimSpace(c.Query("tag"))))
 	if err != nil {
 		log.Error().Err(err).Msg("GetStudyGroupLeaderboard error")
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get leaderboard"})
@@ -370,6 +432,15 @@ func GetAttemptDetail(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "forbidden"})
 	}
 
+	allowed, err := q.CanViewQuiz(detail.Attempt.QuizID, userID, c.Query("share_token"))
+	if err != nil {
+		log.Printf("CanViewQuiz error: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get attempt detail"})
+	}
+	if !allowed {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "attempt not found"})
+	}
+
 	answerMap := map[string]string{}
 	for _, a := range detail.Answers {
 		answerMap[a.QuestionID.String()] = a.SelectedOptionID.String()
@@ -437,6 +508,23 @@ func GetQuizFile(c *fiber.Ctx) error {
 	if id == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "file id is required"})
 	}
+	quizID, err := uuid.Parse(id)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid file id"})
+	}
+	viewer, err := utils.ExtractUserID(c)
+	if err != nil {
+		viewer = uuid.Nil
+	}
+	q := queries.QuizQueries{DB: database.DB}
+	allowed, err := q.CanViewQuiz(quizID, viewer, c.Query("share_token"))
+	if err != nil {
+		log.Error().Err(err).Msg("CanViewQuiz error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to fetch file"})
+	}
+	if !allowed {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "file not found"})
+	}
 
 	data, contentType, err := utils.GetFile(id)
 	if err != nil {
@@ -489,6 +577,12 @@ func AddQuizToStudyGroup(c *fiber.Ctx) error {
 		log.Error().Err(err).Msg("AssignQuizToStudyGroup error")
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to assign quiz to study group"})
 	}
+	if groupID, err := uuid.Parse(req.StudyGroupID); err == nil {
+		emitStudyGroupNotification(groupID, userID, utils.NotificationQuizAssigned, &quiz.ID, map[string]interface{}{
+			"study_group_id": groupID.String(),
+			"quiz_title":     quiz.Title,
+		})
+	}
 	log.Info().Str("quiz_id", req.QuizID).Str("study_group_id", req.StudyGroupID).Msg("quiz assigned to study group")
 
 	return c.JSON(fiber.Map{"message": "quiz assigned to study group successfully"})
@@ -507,8 +601,13 @@ func GetQuizzesByStudyGroup(c *fiber.Ctx) error {
 		}
 	}
 
+	viewer, err := utils.ExtractUserID(c)
+	if err != nil {
+		viewer = uuid.Nil
+	}
+
 	q := queries.QuizQueries{DB: database.DB}
-	quizzes, err := q.GetQuizzesByStudyGroup(id, limit)
+	quizzes, err := q.GetQuizzesByStudyGroup(id, viewer, limit)
 	if err != nil {
 		log.Error().Err(err).Msg("GetQuizzesByStudyGroup error")
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get quizzes for study group"})
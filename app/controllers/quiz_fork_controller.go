@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gilanghuda/backend-Quizzo/app/queries"
+	"github.com/gilanghuda/backend-Quizzo/pkg/database"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+func ForkQuiz(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	sourceID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid quiz id"})
+	}
+
+	var req struct {
+		Title string `json:"title"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
+		}
+	}
+	title := strings.TrimSpace(req.Title)
+	if len(title) > 255 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "title is too long"})
+	}
+
+	q := queries.QuizQueries{DB: database.DB}
+	forkID, err := q.ForkQuiz(sourceID, userID, title)
+	if err != nil {
+		switch {
+		case errors.Is(err, queries.ErrForkSourceNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, queries.ErrForkingDisabled):
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Error().Err(err).Str("quiz_id", sourceID.String()).Msg("ForkQuiz error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to fork quiz"})
+	}
+	log.Info().Str("quiz_id", sourceID.String()).Str("fork_id", forkID.String()).Str("user_id", userID.String()).Msg("quiz forked")
+
+	fork, err := q.GetQuizByID(forkID.String())
+	if err != nil || fork == nil {
+		log.Error().Err(err).Str("fork_id", forkID.String()).Msg("GetQuizByID error after fork")
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{"quiz_id": forkID})
+	}
+	if fork.ForkedFrom != nil && fork.ForkedFrom.AuthorID != "" {
+		if ownerID, err := uuid.Parse(fork.ForkedFrom.AuthorID); err == nil {
+			emitNotification(ownerID, userID, utils.NotificationQuizForked, &sourceID, map[string]interface{}{"fork_id": forkID.String()})
+		}
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"quiz": fork})
+}
+
+func UpdateQuizForking(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	quizID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid quiz id"})
+	}
+
+	var req struct {
+		AllowForking *bool `json:"allow_forking"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.AllowForking == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "allow_forking is required"})
+	}
+
+	q := queries.QuizQueries{DB: database.DB}
+	if err := q.SetAllowForking(quizID, userID, *req.AllowForking); err != nil {
+		log.Error().Err(err).Str("quiz_id", quizID.String()).Msg("SetAllowForking error")
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	log.Info().Str("quiz_id", quizID.String()).Bool("allow_forking", *req.AllowForking).Msg("quiz forking updated")
+	return c.JSON(fiber.Map{"allow_forking": *req.AllowForking})
+}
+
+func GetQuizForks(c *fiber.Ctx) error {
+	quizID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid quiz id"})
+	}
+	limit, offset := parseLimitOffset(c, 20)
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	q := queries.QuizQueries{DB: database.DB}
+	forks, err := q.GetQuizForks(quizID, limit, offset)
+	if err != nil {
+		log.Error().Err(err).Str("quiz_id", quizID.String()).Msg("GetQuizForks error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get forks"})
+	}
+	return c.JSON(fiber.Map{"forks": forks})
+}
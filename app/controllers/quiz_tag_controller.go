@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"strings"
+
+	"github.com/gilanghuda/backend-Quizzo/app/queries"
+	"github.com/gilanghuda/backend-Quizzo/pkg/database"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// UpdateQuizTags lets the quiz owner correct the subject and tags suggested by the generator
+func UpdateQuizTags(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	quizID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid quiz id"})
+	}
+
+	var req struct {
+		Subject string   `json:"subject"`
+		Tags    []string `json:"tags"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
+	}
+	subject := strings.ToLower(strings.TrimSpace(req.Subject))
+	if !utils.IsValidSubject(subject) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid subject", "subjects": utils.Subjects})
+	}
+	tags := utils.NormalizeTags(req.Tags)
+	if len(tags) > utils.MaxQuizTags {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "too many tags"})
+	}
+	for _, t := range tags {
+		if len(t) > utils.MaxQuizTagLen {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "tag is too long: " + t})
+		}
+	}
+
+	q := queries.QuizQueries{DB: database.DB}
+	if err := q.UpdateQuizTags(quizID, userID, subject, tags); err != nil {
+		log.Error().Err(err).Str("quiz_id", quizID.String()).Msg("UpdateQuizTags error")
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	log.Info().Str("quiz_id", quizID.String()).Str("subject", subject).Strs("tags", tags).Msg("quiz tags updated")
+	return c.JSON(fiber.Map{"subject": subject, "tags": tags})
+}
+
+func GetQuizzesByTag(c *fiber.Ctx) error {
+	return getQuizzesByTag(c, false)
+}
+
+func GetQuizzesBySubject(c *fiber.Ctx) error {
+	return getQuizzesByTag(c, true)
+}
+
+func getQuizzesByTag(c *fiber.Ctx, bySubject bool) error {
+	tag := strings.ToLower(strings.TrimSpace(c.Params("tag")))
+	if tag == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "tag is required"})
+	}
+	if bySubject && !utils.IsValidSubject(tag) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "unknown subject"})
+	}
+	limit, offset := parseLimitOffset(c, 20)
+
+	q := queries.QuizQueries{DB: database.DB}
+	res, err := q.GetQuizzesByTag(tag, bySubject, limit, offset)
+	if err != nil {
+		log.Error().Err(err).Str("tag", tag).Msg("GetQuizzesByTag error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get quizzes"})
+	}
+	return c.JSON(fiber.Map{"quizzes": res})
+}
+
+func GetPopularTags(c *fiber.Ctx) error {
+	q := queries.QuizQueries{DB: database.DB}
+	res, err := q.GetPopularTags(c.QueryInt("limit", 30))
+	if err != nil {
+		log.Error().Err(err).Msg("GetPopularTags error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get tags"})
+	}
+	return c.JSON(fiber.Map{"tags": res})
+}
+
+// GetSubjects returns the subject taxonomy with the number of quizzes in each
+func GetSubjects(c *fiber.Ctx) error {
+	q := queries.QuizQueries{DB: database.DB}
+	counts, err := q.GetSubjectCounts()
+	if err != nil {
+		log.Error().Err(err).Msg("GetSubjectCounts error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get subjects"})
+	}
+	byName := map[string]int{}
+	for _, s := range counts {
+		byName[s.Name] = s.QuizCount
+	}
+	res := make([]fiber.Map, 0, len(utils.Subjects))
+	for _, s := range utils.Subjects {
+		res = append(res, fiber.Map{"name": s, "quiz_count": byName[s]})
+	}
+	return c.JSON(fiber.Map{"subjects": res})
+}
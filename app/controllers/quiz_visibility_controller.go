@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gilanghuda/backend-Quizzo/app/queries"
+	"github.com/gilanghuda/backend-Quizzo/pkg/database"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+func UpdateQuizVisibility(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	quizID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid quiz id"})
+	}
+
+	var req struct {
+		Visibility string `json:"visibility"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
+	}
+	visibility := strings.ToLower(strings.TrimSpace(req.Visibility))
+	if !utils.IsValidVisibility(visibility) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid visibility", "visibilities": utils.ValidVisibilities})
+	}
+
+	q := queries.QuizQueries{DB: database.DB}
+	token, err := q.SetQuizVisibility(quizID, userID, visibility)
+	if err != nil {
+		if errors.Is(err, queries.ErrQuizNotInStudyGroup) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Error().Err(err).Str("quiz_id", quizID.String()).Msg("SetQuizVisibility error")
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	log.Info().Str("quiz_id", quizID.String()).Str("visibility", visibility).Msg("quiz visibility updated")
+
+	res := fiber.Map{"visibility": visibility}
+	if token != nil {
+		res["share_token"] = *token
+	}
+	return c.JSON(res)
+}
+
+func RotateQuizShareToken(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	quizID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid quiz id"})
+	}
+
+	q := queries.QuizQueries{DB: database.DB}
+	token, err := q.RotateQuizShareToken(quizID, userID)
+	if err != nil {
+		log.Error().Err(err).Str("quiz_id", quizID.String()).Msg("RotateQuizShareToken error")
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	log.Info().Str("quiz_id", quizID.String()).Msg("quiz share token rotated")
+	return c.JSON(fiber.Map{"share_token": token})
+}
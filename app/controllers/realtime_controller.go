@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gilanghuda/backend-Quizzo/app/queries"
+	"github.com/gilanghuda/backend-Quizzo/pkg/database"
+	"github.com/gilanghuda/backend-Quizzo/pkg/middleware"
+	"github.com/gilanghuda/backend-Quizzo/pkg/realtime"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const eventBatchSize = 100
+
+// eventCursor reads the replay cursor from the SSE Last-Event-ID header or the cursor query param.
+// ok is false when the client did not send one.
+func eventCursor(c *fiber.Ctx) (int64, bool, error) {
+	v := c.Get("Last-Event-ID")
+	if v == "" {
+		v = c.Query("cursor")
+	}
+	if v == "" {
+		return 0, false, nil
+	}
+	cursor, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || cursor < 0 {
+		return 0, false, fmt.Errorf("invalid cursor")
+	}
+	return cursor, true, nil
+}
+
+// StreamEvents pushes the user's events over Server-Sent Events. Reconnecting clients resume from
+// Last-Event-ID (or ?cursor=) and receive everything they missed; new clients start from now.
+func StreamEvents(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	cursor, ok, err := eventCursor(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	q := queries.UserEventQueries{DB: database.DB}
+	if !ok {
+		if cursor, err = q.GetLatestEventID(userID); err != nil {
+			log.Error().Err(err).Str("user_id", userID.String()).Msg("failed to get latest event id")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to open event stream"})
+		}
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	// API keys carry no session; a JWT stream ends once its session is revoked
+	sessionID, _ := utils.ExtractSessionID(c)
+
+	wake, unsubscribe := realtime.DefaultHub.Subscribe(userID)
+	log.Info().Str("user_id", userID.String()).Int64("cursor", cursor).Msg("event stream opened")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+		heartbeat := time.NewTicker(25 * time.Second)
+		defer heartbeat.Stop()
+
+		if err := writeEventsSince(w, &q, userID, &cursor); err != nil {
+			return
+		}
+		for {
+			select {
+			case <-realtime.DefaultHub.Done():
+				log.Info().Str("user_id", userID.String()).Msg("event stream closed for shutdown")
+				return
+			case <-wake:
+				if err := writeEventsSince(w, &q, userID, &cursor); err != nil {
+					log.Info().Str("user_id", userID.String()).Msg("event stream closed")
+					return
+				}
+			case <-heartbeat.C:
+				if sessionID != uuid.Nil && !middleware.SessionActive(sessionID) {
+					log.Info().Str("user_id", userID.String()).Msg("event stream closed for revoked session")
+					return
+				}
+				fmt.Fprint(w, ": ping\n\n")
+				if err := w.Flush(); err != nil {
+					log.Info().Str("user_id", userID.String()).Msg("event stream closed")
+					return
+				}
+			}
+		}
+	})
+	return nil
+}
+
+func writeEventsSince(w *bufio.Writer, q *queries.UserEventQueries, userID uuid.UUID, cursor *int64) error {
+	for {
+		events, err := q.GetEventsSince(userID, *cursor, eventBatchSize)
+		if err != nil {
+			log.Error().Err(err).Str("user_id", userID.String()).Msg("failed to read user events")
+			return err
+		}
+		for _, e := range events {
+			data, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+			*cursor = e.ID
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if len(events) < eventBatchSize {
+			return nil
+		}
+	}
+}
+
+// GetEvents is the polling fallback for clients that cannot hold a stream open
+func GetEvents(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	cursor, _, err := eventCursor(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	limit := eventBatchSize
+	if l := c.Query("limit"); l != "" {
+		if v, err := strconv.Atoi(l); err == nil && v > 0 && v <= eventBatchSize {
+			limit = v
+		}
+	}
+
+	q := queries.UserEventQueries{DB: database.DB}
+	events, err := q.GetEventsSince(userID, cursor, limit)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("failed to read user events")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get events"})
+	}
+	next := cursor
+	if len(events) > 0 {
+		next = events[len(events)-1].ID
+	}
+	return c.JSON(fiber.Map{"events": events, "cursor": next})
+}
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/gilanghuda/backend-Quizzo/app/queries"
+	"github.com/gilanghuda/backend-Quizzo/pkg/database"
+	"github.com/gilanghuda/backend-Quizzo/pkg/middleware"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+func CreateReport(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var req struct {
+		TargetType string `json:"target_type" validate:"required"`
+		TargetID   string `json:"target_id" validate:"required,uuid"`
+		Reason     string `json:"reason" validate:"required,lte=50"`
+		Details    string `json:"details" validate:"lte=2000"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if err := validate.Struct(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if !utils.IsValidReportTarget(req.TargetType) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid target_type"})
+	}
+	targetID := uuid.MustParse(req.TargetID)
+	if req.TargetType == utils.ReportTargetUser && targetID == userID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "cannot report yourself"})
+	}
+
+	report := &models.Report{
+		ReporterID: userID,
+		TargetType: req.TargetType,
+		TargetID:   targetID,
+		Reason:     req.Reason,
+		Details:    strings.TrimSpace(req.Details),
+	}
+	q := queries.ReportQueries{DB: database.DB}
+	if err := q.CreateReport(report); err != nil {
+		switch {
+		case errors.Is(err, queries.ErrReportTarget):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, queries.ErrReportAlreadyOpen):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("failed to create report")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to create report"})
+	}
+	log.Info().Str("report_id", report.ID.String()).Str("target_type", report.TargetType).Str("target_id", report.TargetID.String()).Msg("report created")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"report": report})
+}
+
+// GetReports is the admin moderation queue. Defaults to open reports.
+func GetReports(c *fiber.Ctx) error {
+	status := c.Query("status", utils.ReportStatusOpen)
+	if status == "all" {
+		status = ""
+	}
+	targetType := c.Query("target_type")
+	if targetType != "" && !utils.IsValidReportTarget(targetType) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid target_type"})
+	}
+	limit, offset := parseLimitOffset(c, 20)
+
+	q := queries.ReportQueries{DB: database.DB}
+	reports, err := q.GetReports(status, targetType, limit, offset)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get reports")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get reports"})
+	}
+	return c.JSON(fiber.Map{"reports": reports})
+}
+
+func GetReport(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid report id"})
+	}
+	q := queries.ReportQueries{DB: database.DB}
+	report, err := q.GetReport(id)
+	if err != nil {
+		log.Error().Err(err).Str("report_id", id.String()).Msg("failed to get report")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get report"})
+	}
+	if report == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "report not found"})
+	}
+	return c.JSON(fiber.Map{"report": report})
+}
+
+func ResolveReport(c *fiber.Ctx) error {
+	adminID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid report id"})
+	}
+
+	var req struct {
+		Action      string `json:"action"`
+		Note        string `json:"note"`
+		SuspendDays *int   `json:"suspend_days"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
+	}
+	switch req.Action {
+	case utils.ModerationDismiss, utils.ModerationHideContent, utils.ModerationSuspendUser:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "action must be dismiss, hide_content or suspend_user"})
+	}
+
+	var suspendUntil *time.Time
+	if req.SuspendDays != nil {
+		if *req.SuspendDays <= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "suspend_days must be positive"})
+		}
+		t := time.Now().AddDate(0, 0, *req.SuspendDays)
+		suspendUntil = &t
+	}
+
+	q := queries.ReportQueries{DB: database.DB}
+	resolved, revoked, err := q.ResolveReport(id, adminID, req.Action, strings.TrimSpace(req.Note), suspendUntil)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "report not found"})
+		case errors.Is(err, queries.ErrReportNotOpen):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, queries.ErrReportTarget):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, queries.ErrSuspendAdmin):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Error().Err(err).Str("report_id", id.String()).Str("action", req.Action).Msg("failed to resolve report")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	middleware.ForgetSessions(revoked...)
+	log.Info().Str("report_id", id.String()).Str("action", req.Action).Str("admin_id", adminID.String()).Int64("resolved", resolved).Msg("report resolved")
+	return c.JSON(fiber.Map{"message": "report resolved", "resolved_reports": resolved})
+}
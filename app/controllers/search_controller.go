@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"strings"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/gilanghuda/backend-Quizzo/app/queries"
+	"github.com/gilanghuda/backend-Quizzo/pkg/database"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+var searchTypes = []string{models.SearchTypeUsers, models.SearchTypeQuizzes, models.SearchTypeStudyGroups}
+
+// Search looks up users, quizzes and public study groups. ?type= narrows it to a comma separated
+// subset, ?difficulty= filters quizzes, and limit/offset page through each type independently.
+func Search(c *fiber.Ctx) error {
+	text := strings.TrimSpace(c.Query("q"))
+	if len([]rune(text)) < 2 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "q must be at least 2 characters"})
+	}
+	if len(text) > 100 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "q is too long"})
+	}
+
+	types := searchTypes
+	if t := c.Query("type"); t != "" {
+		types = nil
+		for _, part := range strings.Split(t, ",") {
+			part = strings.TrimSpace(part)
+			valid := false
+			for _, st := range searchTypes {
+				if part == st {
+					valid = true
+					break
+				}
+			}
+			if !valid {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "type must be users, quizzes or study_groups"})
+			}
+			types = append(types, part)
+		}
+	}
+
+	difficulty := c.Query("difficulty")
+	switch difficulty {
+	case "", "easy", "medium", "hard":
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "difficulty must be easy, medium or hard"})
+	}
+
+	viewer, err := utils.ExtractUserID(c)
+	if err != nil {
+		viewer = uuid.Nil
+	}
+	limit, offset := parseLimitOffset(c, 10)
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	f := models.SearchFilter{Query: text, Types: types, Difficulty: difficulty, ViewerID: viewer, Limit: limit, Offset: offset}
+
+	q := queries.SearchQueries{DB: database.DB}
+	res := models.SearchResults{Query: text}
+	for _, t := range types {
+		switch t {
+		case models.SearchTypeUsers:
+			res.Users, err = q.SearchUsers(f)
+		case models.SearchTypeQuizzes:
+			res.Quizzes, err = q.SearchQuizzes(f)
+		case models.SearchTypeStudyGroups:
+			res.StudyGroups, err = q.SearchStudyGroups(f)
+		}
+		if err != nil {
+			log.Error().Err(err).Str("type", t).Str("query", text).Msg("search failed")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "search failed"})
+		}
+	}
+
+	log.Info().Str("query", text).Strs("types", types).Msg("search performed")
+	return c.JSON(res)
+}
@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/gilanghuda/backend-Quizzo/app/queries"
+	"github.com/gilanghuda/backend-Quizzo/pkg/database"
+	"github.com/gilanghuda/backend-Quizzo/pkg/middleware"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+type sessionTokens struct {
+	AccessToken      string    `json:"token"`
+	AccessExpiresAt  time.Time `json:"token_expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_token_expires_at"`
+}
+
+// startSession opens a new server-side session for the user and returns its token pair
+func startSession(c *fiber.Ctx, user *models.User) (*sessionTokens, error) {
+	refresh, hash, err := utils.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	refreshExp := time.Now().Add(utils.RefreshTokenTTL)
+
+	sq := queries.SessionQueries{DB: database.DB}
+	sessionID, err := sq.CreateSession(user.ID, hash, c.Get(fiber.HeaderUserAgent), c.IP(), refreshExp)
+	if err != nil {
+		return nil, err
+	}
+	access, accessExp, err := utils.GenerateAccessToken(user.ID, user.Email, user.UserRole, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	tokens := &sessionTokens{AccessToken: access, AccessExpiresAt: accessExp, RefreshToken: refresh, RefreshExpiresAt: refreshExp}
+	setSessionCookies(c, tokens)
+	return tokens, nil
+}
+
+func setSessionCookies(c *fiber.Ctx, t *sessionTokens) {
+	c.Cookie(&fiber.Cookie{
+		Name:     "token",
+		Value:    t.AccessToken,
+		Expires:  t.AccessExpiresAt,
+		Path:     "/",
+		HTTPOnly: false,
+		Secure:   false,
+		SameSite: "lax",
+	})
+	c.Cookie(&fiber.Cookie{
+		Name:     "refresh_token",
+		Value:    t.RefreshToken,
+		Expires:  t.RefreshExpiresAt,
+		Path:     "/",
+		HTTPOnly: true,
+		Secure:   false,
+		SameSite: "lax",
+	})
+}
+
+func clearSessionCookies(c *fiber.Ctx) {
+	for _, name := range []string{"token", "refresh_token"} {
+		c.Cookie(&fiber.Cookie{
+			Name:     name,
+			Value:    "",
+			Expires:  time.Now().Add(-time.Hour),
+			Path:     "/",
+			HTTPOnly: true,
+			Secure:   false,
+			SameSite: "lax",
+			MaxAge:   -1,
+		})
+	}
+}
+
+// refreshTokenFromRequest reads the refresh token from the JSON body, falling back to the refresh_token cookie
+func refreshTokenFromRequest(c *fiber.Ctx) string {
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if len(c.Body()) > 0 {
+		_ = c.BodyParser(&req)
+	}
+	if req.RefreshToken != "" {
+		return req.RefreshToken
+	}
+	return c.Cookies("refresh_token")
+}
+
+// RefreshSession exchanges a refresh token for a new access token and a rotated refresh token
+func RefreshSession(c *fiber.Ctx) error {
+	token := refreshTokenFromRequest(c)
+	if token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "refresh_token is required"})
+	}
+
+	refresh, hash, err := utils.GenerateRefreshToken()
+	if err != nil {
+		log.Error().Err(err).Msg("failed to generate refresh token")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to refresh session"})
+	}
+	refreshExp := time.Now().Add(utils.RefreshTokenTTL)
+
+	sq := queries.SessionQueries{DB: database.DB}
+	sessionID, user, err := sq.RotateSession(utils.HashToken(token), hash, refreshExp)
+	if err != nil {
+		clearSessionCookies(c)
+		switch {
+		case errors.Is(err, queries.ErrRefreshTokenReused):
+			log.Info().Msg("refresh token reuse detected, session revoked")
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, queries.ErrInvalidRefreshToken):
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Error().Err(err).Msg("RotateSession error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to refresh session"})
+	}
+
+	if user.IsSuspended(time.Now()) {
+		if err := sq.RevokeSession(sessionID, user.ID); err != nil {
+			log.Error().Err(err).Str("session_id", sessionID.String()).Msg("failed to revoke session of suspended user")
+		}
+		middleware.ForgetSessions(sessionID)
+		clearSessionCookies(c)
+		return c.Status(fiber.StatusForbidden).JSON(suspendedResponse(user))
+	}
+
+	access, accessExp, err := utils.GenerateAccessToken(user.ID, user.Email, user.UserRole, sessionID)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to sign access token")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to refresh session"})
+	}
+	tokens := &sessionTokens{AccessToken: access, AccessExpiresAt: accessExp, RefreshToken: refresh, RefreshExpiresAt: refreshExp}
+	setSessionCookies(c, tokens)
+	return c.JSON(tokens)
+}
+
+func GetSessions(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	current, _ := utils.ExtractSessionID(c)
+
+	sq := queries.SessionQueries{DB: database.DB}
+	sessions, err := sq.GetActiveSessions(userID, current)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("GetActiveSessions error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get sessions"})
+	}
+	return c.JSON(fiber.Map{"sessions": sessions})
+}
+
+func RevokeSession(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	sessionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid session id"})
+	}
+
+	sq := queries.SessionQueries{DB: database.DB}
+	if err := sq.RevokeSession(sessionID, userID); err != nil {
+		if errors.Is(err, queries.ErrSessionNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Error().Err(err).Str("session_id", sessionID.String()).Msg("RevokeSession error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to revoke session"})
+	}
+	middleware.ForgetSessions(sessionID)
+	if current, err := utils.ExtractSessionID(c); err == nil && current == sessionID {
+		clearSessionCookies(c)
+	}
+	log.Info().Str("user_id", userID.String()).Str("session_id", sessionID.String()).Msg("session revoked")
+	return c.JSON(fiber.Map{"message": "session revoked"})
+}
+
+// RevokeAllSessions logs the user out everywhere. With keep_current=true the calling device stays signed in.
+func RevokeAllSessions(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	keep := uuid.Nil
+	if c.QueryBool("keep_current", false) {
+		keep, _ = utils.ExtractSessionID(c)
+	}
+
+	sq := queries.SessionQueries{DB: database.DB}
+	revoked, err := sq.RevokeAllSessions(userID, keep)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("RevokeAllSessions error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to revoke sessions"})
+	}
+	middleware.ForgetSessions(revoked...)
+	if keep == uuid.Nil {
+		clearSessionCookies(c)
+	}
+	log.Info().Str("user_id", userID.String()).Int("count", len(revoked)).Msg("sessions revoked")
+	return c.JSON(fiber.Map{"revoked": len(revoked)})
+}
@@ -1,12 +1,15 @@
 package controllers
 
 import (
+	"errors"
 	"strconv"
 
+	"github.com/gilanghuda/backend-Quizzo/app/models"
 	"github.com/gilanghuda/backend-Quizzo/app/queries"
 	"github.com/gilanghuda/backend-Quizzo/pkg/database"
 	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 )
 
@@ -25,6 +28,9 @@ func ToggleLike(c *fiber.Ctx) error {
 		log.Error().Err(err).Str("quiz_id", quizID).Str("user_id", userID.String()).Msg("ToggleLike error")
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to toggle like"})
 	}
+	if liked {
+		notifyQuizOwner(quizID, userID, utils.NotificationLike, nil)
+	}
 	count, _ := q.CountLikes(quizID)
 	log.Info().Str("quiz_id", quizID).Str("user_id", userID.String()).Bool("liked", liked).Int("likes_count", count).Msg("toggle like result")
 	return c.JSON(fiber.Map{"liked": liked, "likes_count": count})
@@ -55,7 +61,8 @@ func AddComment(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "quiz id required"})
 	}
 	var req struct {
-		Content string `json:"content"`
+		Content  string  `json:"content"`
+		ParentID *string `json:"parent_id"`
 	}
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
@@ -63,16 +70,145 @@ func AddComment(c *fiber.Ctx) error {
 	if req.Content == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "content required"})
 	}
+	var parentID *uuid.UUID
+	if req.ParentID != nil && *req.ParentID != "" {
+		pid, err := uuid.Parse(*req.ParentID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid parent_id"})
+		}
+		parentID = &pid
+	}
+	quizUUID, err := uuid.Parse(quizID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid quiz id"})
+	}
+	qq := queries.QuizQueries{DB: database.DB}
+	allowed, err := qq.CanViewQuiz(quizUUID, userID, c.Query("share_token"))
+	if err != nil {
+		log.Error().Err(err).Str("quiz_id", quizID).Msg("CanViewQuiz error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to add comment"})
+	}
+	if !allowed {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": queries.ErrCommentQuizNotFound.Error()})
+	}
 	q := queries.SocialsQueries{DB: database.DB}
-	commentID, err := q.AddComment(quizID, userID.String(), req.Content)
+	commentID, err := q.AddComment(quizID, userID.String(), req.Content, parentID)
 	if err != nil {
 		log.Error().Err(err).Str("quiz_id", quizID).Str("user_id", userID.String()).Msg("AddComment error")
+		switch {
+		case errors.Is(err, queries.ErrParentCommentNotFound):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, queries.ErrCommentQuizNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, queries.ErrUserBlocked):
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to add comment"})
 	}
+
+	cid := uuid.MustParse(commentID)
+	notifyQuizOwner(quizID, userID, utils.NotificationComment, map[string]interface{}{"comment_id": commentID})
+	if parentID != nil {
+		if parent, err := q.GetComment(*parentID); err == nil && parent != nil {
+			emitNotification(parent.CommenterBy, userID, utils.NotificationReply, &parent.QuizID, map[string]interface{}{"comment_id": commentID, "parent_id": parent.ID.String()})
+		}
+	}
+	notifyMentions(&q, cid, quizID, userID, req.Content)
+
 	log.Info().Str("quiz_id", quizID).Str("user_id", userID.String()).Str("comment_id", commentID).Msg("comment added")
 	return c.JSON(fiber.Map{"comment_id": commentID})
 }
 
+// notifyMentions stores the @username mentions of a comment and notifies users mentioned for the first time
+func notifyMentions(q *queries.SocialsQueries, commentID uuid.UUID, quizID string, actor uuid.UUID, content string) {
+	mentioned, err := q.SyncMentions(commentID, utils.ExtractMentions(content))
+	if err != nil {
+		log.Error().Err(err).Str("comment_id", commentID.String()).Msg("failed to store mentions")
+		return
+	}
+	quizUUID, err := uuid.Parse(quizID)
+	if err != nil {
+		return
+	}
+	for _, uid := range mentioned {
+		emitNotification(uid, actor, utils.NotificationMention, &quizUUID, map[string]interface{}{"comment_id": commentID.String()})
+	}
+}
+
+func EditComment(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	commentID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid comment id"})
+	}
+	var req struct {
+		Content string `json:"content"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
+	}
+	if req.Content == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "content required"})
+	}
+
+	q := queries.SocialsQueries{DB: database.DB}
+	if err := q.EditComment(commentID, userID, req.Content); err != nil {
+		log.Error().Err(err).Str("comment_id", commentID.String()).Str("user_id", userID.String()).Msg("EditComment error")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	comment, err := q.GetComment(commentID)
+	if err != nil || comment == nil {
+		log.Error().Err(err).Str("comment_id", commentID.String()).Msg("GetComment error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get comment"})
+	}
+	notifyMentions(&q, commentID, comment.QuizID.String(), userID, req.Content)
+	if comment, err = q.GetComment(commentID); err != nil || comment == nil {
+		log.Error().Err(err).Str("comment_id", commentID.String()).Msg("GetComment error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get comment"})
+	}
+
+	log.Info().Str("comment_id", commentID.String()).Str("user_id", userID.String()).Msg("comment edited")
+	return c.JSON(fiber.Map{"comment": comment})
+}
+
+func GetCommentHistory(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	commentID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid comment id"})
+	}
+	q := queries.SocialsQueries{DB: database.DB}
+	quizID, err := q.GetVisibleCommentQuiz(commentID, userID)
+	if err != nil {
+		log.Error().Err(err).Str("comment_id", commentID.String()).Msg("GetVisibleCommentQuiz error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get comment history"})
+	}
+	if quizID == uuid.Nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "comment not found"})
+	}
+	qq := queries.QuizQueries{DB: database.DB}
+	allowed, err := qq.CanViewQuiz(quizID, userID, c.Query("share_token"))
+	if err != nil {
+		log.Error().Err(err).Str("comment_id", commentID.String()).Msg("CanViewQuiz error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get comment history"})
+	}
+	if !allowed {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "comment not found"})
+	}
+	history, err := q.GetCommentHistory(commentID)
+	if err != nil {
+		log.Error().Err(err).Str("comment_id", commentID.String()).Msg("GetCommentHistory error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get comment history"})
+	}
+	return c.JSON(fiber.Map{"history": history})
+}
+
 func DeleteComment(c *fiber.Ctx) error {
 	userID, err := utils.ExtractUserID(c)
 	if err != nil {
@@ -91,23 +227,108 @@ func DeleteComment(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"message": "deleted"})
 }
 
-func GetComments(c *fiber.Ctx) error {
-	quizID := c.Params("id")
-	if quizID == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "quiz id required"})
-	}
+// parseCommentPaging reads the cursor and limit query params shared by the comment listing endpoints
+func parseCommentPaging(c *fiber.Ctx) (*models.CommentCursor, int, error) {
 	limit := 20
 	if l := c.Query("limit"); l != "" {
 		if v, err := strconv.Atoi(l); err == nil {
 			limit = v
 		}
 	}
+	var cursor *models.CommentCursor
+	if cs := c.Query("cursor"); cs != "" {
+		cursor = &models.CommentCursor{}
+		if err := utils.DecodeCursor(cs, cursor); err != nil {
+			return nil, 0, err
+		}
+	}
+	return cursor, limit, nil
+}
+
+func GetComments(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	quizID := c.Params("id")
+	if quizID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "quiz id required"})
+	}
+	cursor, limit, err := parseCommentPaging(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	quizUUID, err := uuid.Parse(quizID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid quiz id"})
+	}
+	qq := queries.QuizQueries{DB: database.DB}
+	allowed, err := qq.CanViewQuiz(quizUUID, userID, c.Query("share_token"))
+	if err != nil {
+		log.Error().Err(err).Str("quiz_id", quizID).Msg("CanViewQuiz error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get comments"})
+	}
+	if !allowed {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": queries.ErrCommentQuizNotFound.Error()})
+	}
 	q := queries.SocialsQueries{DB: database.DB}
-	comments, err := q.GetComments(quizID, limit)
+	page, err := q.GetComments(quizID, userID, cursor, limit)
 	if err != nil {
 		log.Error().Err(err).Str("quiz_id", quizID).Msg("GetComments error")
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get comments"})
 	}
-	log.Info().Str("quiz_id", quizID).Int("count", len(comments)).Msg("comments retrieved")
-	return c.JSON(fiber.Map{"comments": comments})
+	log.Info().Str("quiz_id", quizID).Int("count", len(page.Comments)).Msg("comments retrieved")
+	return c.JSON(page)
+}
+
+func GetCommentReplies(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	commentID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid comment id"})
+	}
+	cursor, limit, err := parseCommentPaging(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	q := queries.SocialsQueries{DB: database.DB}
+	quizID, err := q.GetVisibleCommentQuiz(commentID, userID)
+	if err != nil {
+		log.Error().Err(err).Str("comment_id", commentID.String()).Msg("GetVisibleCommentQuiz error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get replies"})
+	}
+	if quizID == uuid.Nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "comment not found"})
+	}
+	qq := queries.QuizQueries{DB: database.DB}
+	allowed, err := qq.CanViewQuiz(quizID, userID, c.Query("share_token"))
+	if err != nil {
+		log.Error().Err(err).Str("comment_id", commentID.String()).Msg("CanViewQuiz error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get replies"})
+	}
+	if !allowed {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "comment not found"})
+	}
+	page, err := q.GetReplies(commentID, userID, cursor, limit)
+	if err != nil {
+		log.Error().Err(err).Str("comment_id", commentID.String()).Msg("GetReplies error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get replies"})
+	}
+	log.Info().Str("comment_id", commentID.String()).Int("count", len(page.Comments)).Msg("comment replies retrieved")
+	return c.JSON(page)
+}
+
+// notifyQuizOwner tells the quiz's author about activity on it
+func notifyQuizOwner(quizID string, actor uuid.UUID, typ string, data map[string]interface{}) {
+	qq := queries.QuizQueries{DB: database.DB}
+	owner, err := qq.GetQuizOwnerID(quizID)
+	if err != nil {
+		log.Error().Err(err).Str("quiz_id", quizID).Msg("failed to get quiz owner for notification")
+		return
+	}
+	id := uuid.MustParse(quizID)
+	emitNotification(owner, actor, typ, &id, data)
 }
@@ -0,0 +1,84 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gilanghuda/backend-Quizzo/app/queries"
+	"github.com/gilanghuda/backend-Quizzo/pkg/database"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+)
+
+// parseDateParam accepts either RFC3339 timestamps or plain YYYY-MM-DD dates
+func parseDateParam(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", v)
+}
+
+// parseDateRange reads from/to query params, defaulting to the last 30 days. A date-only "to" includes that whole day.
+func parseDateRange(c *fiber.Ctx) (time.Time, time.Time, error) {
+	to := time.Now()
+	if v := c.Query("to"); v != "" {
+		t, err := parseDateParam(v)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid to date")
+		}
+		if len(v) == len("2006-01-02") {
+			t = t.AddDate(0, 0, 1)
+		}
+		to = t
+	}
+	from := to.AddDate(0, 0, -30)
+	if v := c.Query("from"); v != "" {
+		t, err := parseDateParam(v)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid from date")
+		}
+		from = t
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, errors.New("from must be before to")
+	}
+	return from, to, nil
+}
+
+func GetStudyGroupAnalytics(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	hardestLimit := 10
+	if l := c.Query("hardest_limit"); l != "" {
+		if v, err := strconv.Atoi(l); err == nil {
+			hardestLimit = v
+		}
+	}
+
+	q := queries.StudyGroupQueries{DB: database.DB}
+	sg, ferr := getOwnedStudyGroup(c, &q, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	analytics, err := q.GetStudyGroupAnalytics(sg.ID, from, to, hardestLimit)
+	if err != nil {
+		log.Error().Err(err).Str("study_group_id", sg.ID.String()).Msg("failed to get study group analytics")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get study group analytics"})
+	}
+	if analytics == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "study group not found"})
+	}
+	log.Info().Str("study_group_id", sg.ID.String()).Time("from", from).Time("to", to).Msg("study group analytics retrieved")
+	return c.JSON(fiber.Map{"analytics": analytics})
+}
@@ -1,7 +1,9 @@
 package controllers
 
 import (
+	"errors"
 	"strconv"
+	"time"
 
 	"github.com/gilanghuda/backend-Quizzo/app/models"
 	"github.com/gilanghuda/backend-Quizzo/app/queries"
@@ -44,7 +46,7 @@ func CreateStudyGroup(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := studyGroupQueries.JoinStudyGroup(created.ID, userID); err != nil {
+	if _, err := studyGroupQueries.JoinStudyGroup(created.ID, userID); err != nil {
 		log.Error().Err(err).Str("study_group_id", created.ID.String()).Msg("Failed to add creator as member")
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to add creator as member"})
 	}
@@ -54,6 +56,10 @@ func CreateStudyGroup(c *fiber.Ctx) error {
 }
 
 func GetStudyGroup(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
 	idStr := c.Params("id")
 	id, err := uuid.Parse(idStr)
 	if err != nil {
@@ -69,6 +75,10 @@ func GetStudyGroup(c *fiber.Ctx) error {
 		log.Info().Str("study_group_id", id.String()).Msg("study group not found")
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "study group not found"})
 	}
+	if err := hideInviteCodeFromNonMember(&q, sg, userID); err != nil {
+		log.Error().Err(err).Str("study_group_id", id.String()).Msg("failed to check membership")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get study group"})
+	}
 	log.Info().Str("study_group_id", id.String()).Msg("study group retrieved")
 	return c.JSON(fiber.Map{"study_group": sg})
 }
@@ -129,16 +139,20 @@ func JoinStudyGroup(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to lookup study group"})
 	}
 	if group == nil {
-		log.Info().Str("invitation_code", req.InvitationCode).Msg("study group not found for invite code")
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "study group not found or invalid invitation code"})
+		return joinStudyGroupWithInvite(c, &q, req.InvitationCode, userID)
 	}
 	if group.IsPrivate {
 		// invite code matched so allow join
 	}
-	if err := q.JoinStudyGroup(group.ID, userID); err != nil {
+	joined, err := q.JoinStudyGroup(group.ID, userID)
+	if err != nil {
 		log.Error().Err(err).Str("study_group_id", group.ID.String()).Str("user_id", userID.String()).Msg("failed to join study group")
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to join study group"})
 	}
+	// joining again is a no-op and must not notify the owner every time
+	if joined {
+		emitNotification(group.CreatedBy, userID, utils.NotificationGroupJoin, &group.ID, map[string]interface{}{"study_group_name": group.Name})
+	}
 	log.Info().Str("study_group_id", group.ID.String()).Str("user_id", userID.String()).Msg("user joined study group")
 	return c.JSON(fiber.Map{"message": "joined"})
 }
@@ -198,6 +212,10 @@ func GetUserStudyGroups(c *fiber.Ctx) error {
 }
 
 func GetStudyGroupDetail(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
 	idStr := c.Params("id")
 	if idStr == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "group id required"})
@@ -216,6 +234,54 @@ func GetStudyGroupDetail(c *fiber.Ctx) error {
 		log.Info().Str("study_group_id", id.String()).Msg("study group detail not found")
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "study group not found"})
 	}
+	if err := hideInviteCodeFromNonMember(&q, &detail.Group, userID); err != nil {
+		log.Error().Err(err).Str("study_group_id", id.String()).Msg("failed to check membership")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get study group detail"})
+	}
 	log.Info().Str("study_group_id", id.String()).Msg("study group detail retrieved")
 	return c.JSON(fiber.Map{"detail": detail})
 }
+
+func joinStudyGroupWithInvite(c *fiber.Ctx, q *queries.StudyGroupQueries, code string, userID uuid.UUID) error {
+	invite, err := q.GetInviteByCode(code)
+	if err != nil {
+		log.Error().Err(err).Str("invitation_code", code).Msg("failed to lookup invite")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to lookup study group"})
+	}
+	if invite == nil {
+		log.Info().Str("invitation_code", code).Msg("study group not found for invite code")
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "study group not found or invalid invitation code"})
+	}
+	if !invite.IsUsable(time.Now()) {
+		return c.Status(fiber.StatusGone).JSON(fiber.Map{"error": queries.ErrInviteNotUsable.Error()})
+	}
+
+	groupID, err := q.JoinStudyGroupWithInvite(invite.ID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, queries.ErrInviteNotUsable):
+			return c.Status(fiber.StatusGone).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, queries.ErrAlreadyMember):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Error().Err(err).Str("invite_id", invite.ID.String()).Str("user_id", userID.String()).Msg("failed to join study group with invite")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to join study group"})
+	}
+	if group, err := q.GetStudyGroup(groupID); err == nil && group != nil {
+		emitNotification(group.CreatedBy, userID, utils.NotificationGroupJoin, &group.ID, map[string]interface{}{"study_group_name": group.Name})
+	}
+	log.Info().Str("study_group_id", groupID.String()).Str("invite_id", invite.ID.String()).Str("user_id", userID.String()).Msg("user joined study group with invite")
+	return c.JSON(fiber.Map{"message": "joined"})
+}
+
+// hideInviteCodeFromNonMember clears the invite code unless userID belongs to the group
+func hideInviteCodeFromNonMember(q *queries.StudyGroupQueries, sg *models.StudyGroup, userID uuid.UUID) error {
+	isMember, err := q.IsStudyGroupMember(sg.ID, userID)
+	if err != nil {
+		return err
+	}
+	if !isMember {
+		sg.InviteCode = nil
+	}
+	return nil
+}
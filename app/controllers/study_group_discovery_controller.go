@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/gilanghuda/backend-Quizzo/app/queries"
+	"github.com/gilanghuda/backend-Quizzo/pkg/database"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+)
+
+func DiscoverStudyGroups(c *fiber.Ctx) error {
+	limit, offset := parseLimitOffset(c, 20)
+	filter := models.StudyGroupDiscoveryFilter{
+		Query:    strings.TrimSpace(c.Query("q")),
+		HasSpace: c.QueryBool("has_space", false),
+		Sort:     c.Query("sort"),
+		Limit:    limit,
+		Offset:   offset,
+	}
+	if t := c.Query("tags"); t != "" {
+		filter.Tags = strings.Split(t, ",")
+	}
+	if v := c.Query("min_members"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid min_members"})
+		}
+		filter.MinMembers = &n
+	}
+	if v := c.Query("max_members"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid max_members"})
+		}
+		filter.MaxMembers = &n
+	}
+	if v := c.Query("active_within_days"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid active_within_days"})
+		}
+		filter.ActiveWithinDays = n
+	}
+
+	q := queries.StudyGroupQueries{DB: database.DB}
+	res, err := q.DiscoverStudyGroups(filter)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to discover study groups")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to discover study groups"})
+	}
+	log.Info().Str("q", filter.Query).Int("count", len(res)).Msg("study groups discovered")
+	return c.JSON(fiber.Map{"study_groups": res})
+}
+
+func RecommendStudyGroups(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	limit := 10
+	if l := c.Query("limit"); l != "" {
+		if v, err := strconv.Atoi(l); err == nil {
+			limit = v
+		}
+	}
+
+	q := queries.StudyGroupQueries{DB: database.DB}
+	res, err := q.RecommendStudyGroups(userID, limit)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("failed to recommend study groups")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get recommendations"})
+	}
+	log.Info().Str("user_id", userID.String()).Int("count", len(res)).Msg("study group recommendations retrieved")
+	return c.JSON(fiber.Map{"recommendations": res})
+}
@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/gilanghuda/backend-Quizzo/app/queries"
+	"github.com/gilanghuda/backend-Quizzo/pkg/database"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// getOwnedStudyGroup loads the study group from the :id param and checks that userID created it
+func getOwnedStudyGroup(c *fiber.Ctx, q *queries.StudyGroupQueries, userID uuid.UUID) (*models.StudyGroup, *fiber.Error) {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	sg, err := q.GetStudyGroup(id)
+	if err != nil {
+		log.Error().Err(err).Str("study_group_id", id.String()).Msg("failed to get study group")
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "failed to get study group")
+	}
+	if sg == nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, "study group not found")
+	}
+	if sg.CreatedBy != userID {
+		return nil, fiber.NewError(fiber.StatusForbidden, "forbidden")
+	}
+	return sg, nil
+}
+
+func CreateStudyGroupInvite(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var req struct {
+		ExpiresInHours *int `json:"expires_in_hours"`
+		MaxUses        *int `json:"max_uses"`
+		SingleUse      bool `json:"single_use"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
+	}
+	if req.ExpiresInHours != nil && *req.ExpiresInHours <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "expires_in_hours must be positive"})
+	}
+	if req.MaxUses != nil && *req.MaxUses <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "max_uses must be positive"})
+	}
+	if req.SingleUse {
+		one := 1
+		req.MaxUses = &one
+	}
+
+	q := queries.StudyGroupQueries{DB: database.DB}
+	sg, ferr := getOwnedStudyGroup(c, &q, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInHours != nil {
+		t := time.Now().Add(time.Duration(*req.ExpiresInHours) * time.Hour)
+		expiresAt = &t
+	}
+
+	invite, err := q.CreateInvite(sg.ID, userID, expiresAt, req.MaxUses)
+	if err != nil {
+		log.Error().Err(err).Str("study_group_id", sg.ID.String()).Msg("failed to create invite")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to create invite"})
+	}
+	log.Info().Str("study_group_id", sg.ID.String()).Str("invite_id", invite.ID.String()).Msg("study group invite created")
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"invite": invite})
+}
+
+func GetStudyGroupInvites(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	q := queries.StudyGroupQueries{DB: database.DB}
+	sg, ferr := getOwnedStudyGroup(c, &q, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	invites, err := q.GetInvitesForGroup(sg.ID)
+	if err != nil {
+		log.Error().Err(err).Str("study_group_id", sg.ID.String()).Msg("failed to get invites")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get invites"})
+	}
+	log.Info().Str("study_group_id", sg.ID.String()).Int("count", len(invites)).Msg("study group invites retrieved")
+	return c.JSON(fiber.Map{"invites": invites})
+}
+
+func RevokeStudyGroupInvite(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	inviteID, err := uuid.Parse(c.Params("inviteId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid invite id"})
+	}
+
+	q := queries.StudyGroupQueries{DB: database.DB}
+	sg, ferr := getOwnedStudyGroup(c, &q, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	if err := q.RevokeInvite(sg.ID, inviteID); err != nil {
+		if errors.Is(err, queries.ErrInviteNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Error().Err(err).Str("study_group_id", sg.ID.String()).Str("invite_id", inviteID.String()).Msg("failed to revoke invite")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to revoke invite"})
+	}
+	log.Info().Str("study_group_id", sg.ID.String()).Str("invite_id", inviteID.String()).Msg("study group invite revoked")
+	return c.JSON(fiber.Map{"message": "revoked"})
+}
+
+func RotateStudyGroupInviteCode(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	q := queries.StudyGroupQueries{DB: database.DB}
+	sg, ferr := getOwnedStudyGroup(c, &q, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	code, err := q.RotateInviteCode(sg.ID)
+	if err != nil {
+		log.Error().Err(err).Str("study_group_id", sg.ID.String()).Msg("failed to rotate invite code")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to rotate invite code"})
+	}
+	log.Info().Str("study_group_id", sg.ID.String()).Msg("study group invite code rotated")
+	return c.JSON(fiber.Map{"invite_code": code})
+}
+
+func GetStudyGroupInviteAudit(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	q := queries.StudyGroupQueries{DB: database.DB}
+	sg, ferr := getOwnedStudyGroup(c, &q, userID)
+	if ferr != nil {
+		return c.Status(ferr.Code).JSON(fiber.Map{"error": ferr.Message})
+	}
+
+	audit, err := q.GetInviteAudit(sg.ID)
+	if err != nil {
+		log.Error().Err(err).Str("study_group_id", sg.ID.String()).Msg("failed to get invite audit")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get invite audit"})
+	}
+	log.Info().Str("study_group_id", sg.ID.String()).Int("count", len(audit)).Msg("study group invite audit retrieved")
+	return c.JSON(fiber.Map{"members": audit})
+}
@@ -0,0 +1,390 @@
+package controllers
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/gilanghuda/backend-Quizzo/app/queries"
+	"github.com/gilanghuda/backend-Quizzo/pkg/database"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+var ErrTwoFactorCodeRequired = errors.New("code or recovery_code is required")
+
+// requireSecondFactor answers with a challenge token instead of a session when the user has 2FA enabled.
+// It reports whether the response has been written.
+func requireSecondFactor(c *fiber.Ctx, user *models.User) (bool, error) {
+	if user.TwoFactorEnabledAt == nil {
+		return false, nil
+	}
+	token, _, exp, err := utils.GenerateTwoFactorChallenge(user.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to create two-factor challenge")
+		return true, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to create token"})
+	}
+	return true, c.JSON(fiber.Map{
+		"two_factor_required":  true,
+		"challenge_token":      token,
+		"challenge_expires_at": exp,
+	})
+}
+
+// verifySecondFactor accepts a current TOTP code or an unused recovery code. Wrong codes count towards
+// a per-user lockout, so starting new sign-in challenges does not give an attacker more guesses.
+func verifySecondFactor(userID uuid.UUID, req *models.TwoFactorCode) error {
+	tq := queries.TwoFactorQueries{DB: database.DB}
+	if err := tq.CheckTwoFactorLock(userID); err != nil {
+		return err
+	}
+	err := checkSecondFactor(&tq, userID, req)
+	switch {
+	case errors.Is(err, queries.ErrInvalidTwoFactorCode):
+		if ferr := tq.RecordTwoFactorFailure(userID); ferr != nil {
+			return ferr
+		}
+	case err == nil:
+		if rerr := tq.ResetTwoFactorFailures(userID); rerr != nil {
+			log.Error().Err(rerr).Str("user_id", userID.String()).Msg("failed to reset two-factor failures")
+		}
+	}
+	return err
+}
+
+func checkSecondFactor(tq *queries.TwoFactorQueries, userID uuid.UUID, req *models.TwoFactorCode) error {
+	if req.RecoveryCode != "" {
+		return tq.UseRecoveryCode(userID, utils.HashToken(utils.NormalizeRecoveryCode(req.RecoveryCode)))
+	}
+	if req.Code == "" {
+		return ErrTwoFactorCodeRequired
+	}
+
+	encrypted, enabled, err := tq.GetTOTPSecret(userID)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return queries.ErrTwoFactorNotEnabled
+	}
+	secret, err := utils.DecryptSecret(encrypted)
+	if err != nil {
+		return err
+	}
+	step, ok := utils.ValidateTOTP(secret, req.Code, time.Now())
+	if !ok {
+		return queries.ErrInvalidTwoFactorCode
+	}
+	return tq.UseTOTPStep(userID, step)
+}
+
+func twoFactorError(c *fiber.Ctx, userID uuid.UUID, err error) error {
+	switch {
+	case errors.Is(err, ErrTwoFactorCodeRequired):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, queries.ErrInvalidTwoFactorCode):
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, queries.ErrTwoFactorLocked):
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, queries.ErrTwoFactorNotEnabled), errors.Is(err, queries.ErrTwoFactorNotPending):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	log.Error().Err(err).Str("user_id", userID.String()).Msg("two-factor verification failed")
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to verify code"})
+}
+
+// VerifyTwoFactorSignIn is the second sign-in step: it trades the challenge token and a code for a session
+func VerifyTwoFactorSignIn(c *fiber.Ctx) error {
+	var req struct {
+		ChallengeToken string `json:"challenge_token"`
+		models.TwoFactorCode
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+	}
+
+	userID, jti, err := utils.ParseTwoFactorChallenge(req.ChallengeToken)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	tq := queries.TwoFactorQueries{DB: database.DB}
+	used, err := tq.IsChallengeUsed(jti)
+	if err != nil {
+		return twoFactorError(c, userID, err)
+	}
+	if used {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": utils.ErrInvalidChallenge.Error()})
+	}
+	if err := verifySecondFactor(userID, &req.TwoFactorCode); err != nil {
+		return twoFactorError(c, userID, err)
+	}
+	// a challenge mints at most one session
+	if err := tq.ConsumeChallenge(jti, userID); err != nil {
+		if errors.Is(err, utils.ErrInvalidChallenge) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		}
+		return twoFactorError(c, userID, err)
+	}
+
+	uq := queries.UserQueries{DB: database.DB}
+	profile, err := uq.GetUserByID(userID)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "user didnt exist"})
+	}
+	user, err := uq.GetUserByEmail(profile.Email)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "user didnt exist"})
+	}
+	if user.IsSuspended(time.Now()) {
+		return c.Status(fiber.StatusForbidden).JSON(suspendedResponse(&user))
+	}
+
+	tokens, err := startSession(c, &user)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to start session")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to generate token"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Sign in successful",
+		"user": fiber.Map{
+			"id":                    user.ID,
+			"email":                 user.Email,
+			"user_role":             user.UserRole,
+			"deletion_scheduled_at": user.DeletionScheduledAt,
+		},
+		"token":                    tokens.AccessToken,
+		"token_expires_at":         tokens.AccessExpiresAt,
+		"refresh_token":            tokens.RefreshToken,
+		"refresh_token_expires_at": tokens.RefreshExpiresAt,
+	})
+}
+
+func GetTwoFactorStatus(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	tq := queries.TwoFactorQueries{DB: database.DB}
+	status, err := tq.GetStatus(userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("failed to get two-factor status")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get two-factor status"})
+	}
+	return c.JSON(status)
+}
+
+// SetupTwoFactor creates a new unconfirmed secret and returns it with the otpauth:// URI for the QR code.
+// 2FA stays off until EnableTwoFactor receives a code generated from it.
+func SetupTwoFactor(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	uq := queries.UserQueries{DB: database.DB}
+	user, err := uq.GetUserByID(userID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "User not found"})
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to generate secret"})
+	}
+	encrypted, err := utils.EncryptSecret(secret)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to encrypt totp secret")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to generate secret"})
+	}
+
+	tq := queries.TwoFactorQueries{DB: database.DB}
+	if err := tq.SetPendingSecret(userID, encrypted); err != nil {
+		if errors.Is(err, queries.ErrTwoFactorAlreadyEnabled) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		log.Error().Err(err).Str("user_id", userID.String()).Msg("failed to store totp secret")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to set up two-factor authentication"})
+	}
+
+	return c.JSON(fiber.Map{
+		"secret":           secret,
+		"provisioning_uri": utils.TOTPProvisioningURI(user.Email, secret),
+	})
+}
+
+// issueRecoveryCodes generates a fresh set of recovery codes and their hashes
+func issueRecoveryCodes() ([]string, []string, error) {
+	codes, err := utils.GenerateRecoveryCodes()
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = utils.HashToken(utils.NormalizeRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// EnableTwoFactor confirms the pending secret with a code from the authenticator and returns the
+// recovery codes; they are shown only this once
+func EnableTwoFactor(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	var req models.TwoFactorCode
+	if err := c.BodyParser(&req); err != nil || strings.TrimSpace(req.Code) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "code is required"})
+	}
+
+	tq := queries.TwoFactorQueries{DB: database.DB}
+	encrypted, enabled, err := tq.GetTOTPSecret(userID)
+	if err != nil {
+		return twoFactorError(c, userID, err)
+	}
+	if enabled {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": queries.ErrTwoFactorAlreadyEnabled.Error()})
+	}
+	if encrypted == "" {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": queries.ErrTwoFactorNotPending.Error()})
+	}
+	secret, err := utils.DecryptSecret(encrypted)
+	if err != nil {
+		return twoFactorError(c, userID, err)
+	}
+	step, ok := utils.ValidateTOTP(secret, req.Code, time.Now())
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": queries.ErrInvalidTwoFactorCode.Error()})
+	}
+
+	codes, hashes, err := issueRecoveryCodes()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to generate recovery codes"})
+	}
+	if err := tq.EnableTwoFactor(userID, step, hashes); err != nil {
+		return twoFactorError(c, userID, err)
+	}
+
+	log.Info().Str("user_id", userID.String()).Msg("two-factor authentication enabled")
+	return c.JSON(fiber.Map{"message": "two-factor authentication enabled", "recovery_codes": codes})
+}
+
+// DisableTwoFactor turns 2FA off after a valid code. Admins cannot while it is mandatory for their role.
+func DisableTwoFactor(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	var req models.TwoFactorCode
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+	}
+
+	tq := queries.TwoFactorQueries{DB: database.DB}
+	status, err := tq.GetStatus(userID)
+	if err != nil {
+		return twoFactorError(c, userID, err)
+	}
+	if status.Required {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "two-factor authentication is mandatory for administrators"})
+	}
+	if !status.Enabled {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": queries.ErrTwoFactorNotEnabled.Error()})
+	}
+	if err := verifySecondFactor(userID, &req); err != nil {
+		return twoFactorError(c, userID, err)
+	}
+	if err := tq.DisableTwoFactor(userID); err != nil {
+		return twoFactorError(c, userID, err)
+	}
+
+	log.Info().Str("user_id", userID.String()).Msg("two-factor authentication disabled")
+	return c.JSON(fiber.Map{"message": "two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after a valid code
+func RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	var req models.TwoFactorCode
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request"})
+	}
+	if err := verifySecondFactor(userID, &req); err != nil {
+		return twoFactorError(c, userID, err)
+	}
+
+	codes, hashes, err := issueRecoveryCodes()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to generate recovery codes"})
+	}
+	tq := queries.TwoFactorQueries{DB: database.DB}
+	if err := tq.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return twoFactorError(c, userID, err)
+	}
+	return c.JSON(fiber.Map{"recovery_codes": codes})
+}
+
+// AdminSetTwoFactorRequirement turns mandatory 2FA for the admin role on or off. The caller must be
+// enrolled before turning it on so they do not lock themselves out of the admin API.
+func AdminSetTwoFactorRequirement(c *fiber.Ctx) error {
+	adminID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	var req struct {
+		Required *bool `json:"required"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.Required == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "required must be true or false"})
+	}
+
+	if *req.Required {
+		tq := queries.TwoFactorQueries{DB: database.DB}
+		status, err := tq.GetStatus(adminID)
+		if err != nil {
+			return twoFactorError(c, adminID, err)
+		}
+		if !status.Enabled {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "enable two-factor authentication on your own account first"})
+		}
+	}
+
+	value := "false"
+	if *req.Required {
+		value = "true"
+	}
+	sq := queries.SettingsQueries{DB: database.DB}
+	if err := sq.SetSetting(utils.SettingAdminRequire2FA, value, adminID); err != nil {
+		log.Error().Err(err).Msg("failed to update setting")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to update setting"})
+	}
+	log.Info().Str("admin_id", adminID.String()).Bool("required", *req.Required).Msg("admin two-factor requirement changed")
+	return c.JSON(fiber.Map{"admin_require_2fa": *req.Required})
+}
+
+// AdminResetTwoFactor turns 2FA off for a user who lost both the authenticator and the recovery codes
+func AdminResetTwoFactor(c *fiber.Ctx) error {
+	adminID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user id"})
+	}
+
+	tq := queries.TwoFactorQueries{DB: database.DB}
+	if err := tq.DisableTwoFactor(userID); err != nil {
+		return twoFactorError(c, userID, err)
+	}
+	log.Info().Str("admin_id", adminID.String()).Str("user_id", userID.String()).Msg("two-factor authentication reset")
+	return c.JSON(fiber.Map{"message": "two-factor authentication reset"})
+}
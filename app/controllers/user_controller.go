@@ -1,6 +1,8 @@
 package controllers
 
 import (
+	"errors"
+
 	"github.com/gilanghuda/backend-Quizzo/app/queries"
 	"github.com/gilanghuda/backend-Quizzo/pkg/database"
 	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
@@ -59,9 +61,14 @@ func FollowUser(c *fiber.Ctx) error {
 	userQueries := queries.UserQueries{DB: database.DB}
 	if err := userQueries.FollowUser(followerID, targetUUID); err != nil {
 		logger.Error().Err(err).Str("follower_id", followerID.String()).Str("target_id", targetUUID.String()).Msg("FollowUser error")
+		if errors.Is(err, queries.ErrUserBlocked) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	emitNotification(targetUUID, followerID, utils.NotificationFollow, &followerID, nil)
+
 	logger.Info().Str("follower_id", followerID.String()).Str("target_id", targetUUID.String()).Msg("user followed")
 	return c.JSON(fiber.Map{"message": "followed"})
 }
@@ -93,6 +100,68 @@ func UnfollowUser(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"message": "unfollowed"})
 }
 
+func BlockUser(c *fiber.Ctx) error {
+	logger := getReqLogger(c)
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	targetUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid target id"})
+	}
+
+	userQueries := queries.UserQueries{DB: database.DB}
+	if err := userQueries.BlockUser(userID, targetUUID); err != nil {
+		logger.Error().Err(err).Str("user_id", userID.String()).Str("target_id", targetUUID.String()).Msg("BlockUser error")
+		if err.Error() == "user not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	logger.Info().Str("user_id", userID.String()).Str("target_id", targetUUID.String()).Msg("user blocked")
+	return c.JSON(fiber.Map{"message": "blocked"})
+}
+
+func UnblockUser(c *fiber.Ctx) error {
+	logger := getReqLogger(c)
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	targetUUID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid target id"})
+	}
+
+	userQueries := queries.UserQueries{DB: database.DB}
+	if err := userQueries.UnblockUser(userID, targetUUID); err != nil {
+		logger.Error().Err(err).Str("user_id", userID.String()).Str("target_id", targetUUID.String()).Msg("UnblockUser error")
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	logger.Info().Str("user_id", userID.String()).Str("target_id", targetUUID.String()).Msg("user unblocked")
+	return c.JSON(fiber.Map{"message": "unblocked"})
+}
+
+func GetBlockedUsers(c *fiber.Ctx) error {
+	logger := getReqLogger(c)
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	limit, offset := parseLimitOffset(c, 20)
+
+	userQueries := queries.UserQueries{DB: database.DB}
+	res, err := userQueries.GetBlockedUsers(userID, limit, offset)
+	if err != nil {
+		logger.Error().Err(err).Str("user_id", userID.String()).Msg("GetBlockedUsers error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get blocked users"})
+	}
+	return c.JSON(fiber.Map{"blocked": res})
+}
+
 func RecommendUsers(c *fiber.Ctx) error {
 	logger := getReqLogger(c)
 	userID, err := utils.ExtractUserID(c)
@@ -100,8 +169,10 @@ func RecommendUsers(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
 	}
 
+	limit := c.QueryInt("limit", 10)
+
 	uq := queries.UserQueries{DB: database.DB}
-	res, err := uq.GetRecommendedUsers(userID, 5)
+	res, err := uq.GetRecommendedUsers(userID, limit)
 	if err != nil {
 		logger.Error().Err(err).Str("user_id", userID.String()).Msg("GetRecommendedUsers error")
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get recommendations"})
@@ -123,6 +194,18 @@ func GetUserByID(c *fiber.Ctx) error {
 	}
 
 	userQueries := queries.UserQueries{DB: database.DB}
+	// blocked profiles are hidden like everywhere else, in either direction
+	if viewer, err := utils.ExtractUserID(c); err == nil && viewer != id {
+		blocked, err := userQueries.IsBlockedEither(viewer, id)
+		if err != nil {
+			logger.Error().Err(err).Str("user_id", id.String()).Msg("IsBlockedEither error")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get user"})
+		}
+		if blocked {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "user not found"})
+		}
+	}
+
 	user, err := userQueries.GetUserByID(id)
 	if err != nil {
 		logger.Error().Err(err).Str("user_id", id.String()).Msg("GetUserByID error")
@@ -130,6 +213,65 @@ func GetUserByID(c *fiber.Ctx) error {
 	}
 
 	user.PasswordHash = ""
+	if viewer, err := utils.ExtractUserID(c); err == nil && viewer != id {
+		following, followedBy, err := userQueries.GetFollowRelation(viewer, id)
+		if err != nil {
+			logger.Error().Err(err).Str("user_id", id.String()).Msg("GetFollowRelation error")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get user"})
+		}
+		mutual := following && followedBy
+		user.IsFollowing, user.FollowsYou, user.IsMutual = &following, &followedBy, &mutual
+		if cnt, err := userQueries.CountMutualFollowers(viewer, id); err == nil {
+			user.MutualCount = &cnt
+		}
+	}
 	logger.Info().Str("user_id", id.String()).Msg("user retrieved by id")
 	return c.Status(fiber.StatusOK).JSON(user)
 }
+
+// GetFollowers lists who follows the user in :id. Flags in each entry are relative to the caller, when signed in.
+func GetFollowers(c *fiber.Ctx) error {
+	return getFollowList(c, true)
+}
+
+// GetFollowing lists who the user in :id follows
+func GetFollowing(c *fiber.Ctx) error {
+	return getFollowList(c, false)
+}
+
+func getFollowList(c *fiber.Ctx, followers bool) error {
+	logger := getReqLogger(c)
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid user id"})
+	}
+	viewer, err := utils.ExtractUserID(c)
+	if err != nil {
+		viewer = uuid.Nil
+	}
+	limit, offset := parseLimitOffset(c, 20)
+
+	userQueries := queries.UserQueries{DB: database.DB}
+	if viewer != uuid.Nil && viewer != id {
+		if blocked, err := userQueries.IsBlockedEither(viewer, id); err != nil {
+			logger.Error().Err(err).Str("user_id", id.String()).Msg("IsBlockedEither error")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get follow list"})
+		} else if blocked {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "user not found"})
+		}
+	}
+
+	key := "following"
+	var res interface{}
+	if followers {
+		key = "followers"
+		res, err = userQueries.GetFollowers(id, viewer, limit, offset)
+	} else {
+		res, err = userQueries.GetFollowing(id, viewer, limit, offset)
+	}
+	if err != nil {
+		logger.Error().Err(err).Str("user_id", id.String()).Str("list", key).Msg("follow list error")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to get follow list"})
+	}
+	return c.JSON(fiber.Map{key: res, "limit": limit, "offset": offset})
+}
@@ -2,7 +2,7 @@ package models
 
 type SignUp struct {
 	Email    string `json:"email" validate:"required,email,lte=255"`
-	Username string `json:"username" validate:"required,lte=255"`
+	Username string `json:"username" validate:"required,min=3,max=30"`
 	Password string `json:"password" validate:"required,lte=255"`
 	UserRole string `json:"user_role,omitempty"`
 }
@@ -11,3 +11,22 @@ type SignIn struct {
 	Email    string `json:"email" validate:"required,email,lte=255"`
 	Password string `json:"password" validate:"required,lte=255"`
 }
+
+type EmailRequest struct {
+	Email string `json:"email" validate:"required,email,lte=255"`
+}
+
+type ResetPassword struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,lte=255"`
+}
+
+type UpdateProfile struct {
+	Username *string `json:"username" validate:"omitempty,min=3,max=30"`
+	Bio      *string `json:"bio" validate:"omitempty,max=500"`
+}
+
+type ChangePassword struct {
+	CurrentPassword string `json:"current_password" validate:"lte=255"`
+	NewPassword     string `json:"new_password" validate:"required,lte=255"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// QuizSummary is the compact quiz shape used in bookmark and collection listings
+type QuizSummary struct {
+	ID             uuid.UUID `json:"id"`
+	Title          string    `json:"title"`
+	Difficulty     string    `json:"difficulty"`
+	CreatedBy      uuid.UUID `json:"created_by"`
+	TotalQuestions int       `json:"total_questions"`
+}
+
+type Bookmark struct {
+	Quiz      QuizSummary `json:"quiz"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+type Collection struct {
+	ID          uuid.UUID        `json:"id"`
+	OwnerID     uuid.UUID        `json:"owner_id"`
+	Name        string           `json:"name"`
+	Description string           `json:"description"`
+	IsPublic    bool             `json:"is_public"`
+	ShareToken  *string          `json:"share_token,omitempty"`
+	ItemCount   int              `json:"item_count"`
+	Items       []CollectionItem `json:"items,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+type CollectionItem struct {
+	Position int         `json:"position"`
+	Quiz     QuizSummary `json:"quiz"`
+	AddedAt  time.Time   `json:"added_at"`
+}
+
+// CollectionRun is a queue of quizzes started from a collection. A quiz counts as done once
+// the user submits an attempt for it after the run started.
+type CollectionRun struct {
+	ID           uuid.UUID   `json:"id"`
+	CollectionID uuid.UUID   `json:"collection_id"`
+	QuizIDs      []uuid.UUID `json:"quiz_ids"`
+	Completed    []uuid.UUID `json:"completed"`
+	NextQuizID   *uuid.UUID  `json:"next_quiz_id"`
+	Finished     bool        `json:"finished"`
+	StartedAt    time.Time   `json:"started_at"`
+}
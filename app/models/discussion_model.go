@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type StudyGroupThread struct {
+	ID             uuid.UUID  `json:"id"`
+	GroupID        uuid.UUID  `json:"group_id"`
+	QuizID         *uuid.UUID `json:"quiz_id,omitempty"`
+	Title          string     `json:"title" validate:"required,lte=255"`
+	Body           string     `json:"body" validate:"lte=10000"`
+	CreatedBy      uuid.UUID  `json:"created_by"`
+	AuthorUsername string     `json:"author_username"`
+	IsAnnouncement bool       `json:"is_announcement"`
+	IsPinned       bool       `json:"is_pinned"`
+	PostCount      int        `json:"post_count"`
+	LastPostAt     time.Time  `json:"last_post_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+type StudyGroupPost struct {
+	ID             uuid.UUID  `json:"id"`
+	ThreadID       uuid.UUID  `json:"thread_id"`
+	ParentID       *uuid.UUID `json:"parent_id,omitempty"`
+	Body           string     `json:"body" validate:"required,lte=10000"`
+	CreatedBy      uuid.UUID  `json:"created_by"`
+	AuthorUsername string     `json:"author_username"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
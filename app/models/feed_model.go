@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	FeedKindNewQuiz         = "new_quiz"
+	FeedKindFriendHighScore = "friend_high_score"
+	FeedKindGroupAssignment = "group_assignment"
+	FeedKindPopularQuiz     = "popular_quiz"
+)
+
+const (
+	FeedModeLatest = "latest"
+	FeedModeRanked = "ranked"
+)
+
+type FeedQuiz struct {
+	ID          uuid.UUID `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Difficulty  string    `json:"difficulty"`
+	CreatedBy   uuid.UUID `json:"created_by"`
+	Attempts    int       `json:"attempts"`
+	LikesCount  int       `json:"likes_count"`
+	IsLikedByMe bool      `json:"is_likedbyme"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type FeedActor struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+}
+
+type FeedGroup struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+// FeedItem is one entry of the activity feed; Kind says which of the optional fields are set
+type FeedItem struct {
+	ItemID         uuid.UUID  `json:"item_id"`
+	Kind           string     `json:"kind"`
+	Quiz           FeedQuiz   `json:"quiz"`
+	Actor          FeedActor  `json:"actor"`
+	Score          *int       `json:"score,omitempty"`
+	TotalQuestions *int       `json:"total_questions,omitempty"`
+	StudyGroup     *FeedGroup `json:"study_group,omitempty"`
+	OccurredAt     time.Time  `json:"occurred_at"`
+	Rank           float64    `json:"rank,omitempty"`
+}
+
+// FeedCursor is the keyset position after the last item of a page. Now pins the ranking clock across pages.
+type FeedCursor struct {
+	Mode       string    `json:"m"`
+	Tag        string    `json:"g,omitempty"`
+	OccurredAt time.Time `json:"t,omitempty"`
+	Rank       float64   `json:"r,omitempty"`
+	Kind       string    `json:"k"`
+	ItemID     uuid.UUID `json:"i"`
+	Now        time.Time `json:"n"`
+}
+
+type FeedPage struct {
+	Items      []FeedItem `json:"feed"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
@@ -13,10 +13,38 @@ type Like struct {
 	CreatedAt time.Time `json:"created_at,omitempty"`
 }
 
+type CommentAuthor struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+	ImageURL *string   `json:"image_url,omitempty"`
+}
+
 type Comment struct {
-	ID          uuid.UUID `json:"id,omitempty"`
-	QuizID      uuid.UUID `json:"quiz_id,omitempty"`
-	Content     string    `json:"content"`
-	CommenterBy uuid.UUID `json:"commenter_by,omitempty"`
-	CreatedAt   time.Time `json:"created_at,omitempty"`
+	ID          uuid.UUID       `json:"id,omitempty"`
+	QuizID      uuid.UUID       `json:"quiz_id,omitempty"`
+	ParentID    *uuid.UUID      `json:"parent_id,omitempty"`
+	Content     string          `json:"content"`
+	CommenterBy uuid.UUID       `json:"commenter_by,omitempty"`
+	Author      CommentAuthor   `json:"author"`
+	Mentions    []CommentAuthor `json:"mentions"`
+	ReplyCount  int             `json:"reply_count"`
+	Edited      bool            `json:"edited"`
+	CreatedAt   time.Time       `json:"created_at,omitempty"`
+	UpdatedAt   *time.Time      `json:"updated_at,omitempty"`
+}
+
+type CommentEdit struct {
+	PreviousContent string    `json:"previous_content"`
+	EditedAt        time.Time `json:"edited_at"`
+}
+
+// CommentCursor is the keyset position after the last comment of a page
+type CommentCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"i"`
+}
+
+type CommentPage struct {
+	Comments   []Comment `json:"comments"`
+	NextCursor string    `json:"next_cursor,omitempty"`
 }
@@ -15,3 +15,8 @@ type StudyGroupLeaderboardEntry struct {
 	MemberCount int       `json:"member_count"`
 	TotalScore  int       `json:"total_score"`
 }
+
+type TagCount struct {
+	Name      string `json:"name"`
+	QuizCount int    `json:"quiz_count"`
+}
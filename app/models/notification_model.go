@@ -0,0 +1,25 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Notification struct {
+	ID            uuid.UUID       `json:"id"`
+	UserID        uuid.UUID       `json:"user_id"`
+	ActorID       *uuid.UUID      `json:"actor_id,omitempty"`
+	ActorUsername *string         `json:"actor_username,omitempty"`
+	Type          string          `json:"type"`
+	EntityID      *uuid.UUID      `json:"entity_id,omitempty"`
+	Data          json.RawMessage `json:"data"`
+	IsRead        bool            `json:"is_read"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+type NotificationPreference struct {
+	Type    string `json:"type"`
+	Enabled bool   `json:"enabled"`
+}
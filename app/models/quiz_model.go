@@ -18,4 +18,21 @@ type Quiz struct {
 	TotalQuestions *int          `json:"total_questions,omitempty"`
 	Questions      []Question    `json:"questions"`
 	CreatedAt      time.Time     `json:"created_at,omitempty"`
+	Subject        string        `json:"subject"`
+	Tags           []string      `json:"tags"`
+	Visibility     string        `json:"visibility"`
+	ShareToken     *string       `json:"share_token,omitempty"`
+	AllowForking   bool          `json:"allow_forking"`
+	ForkCount      int           `json:"fork_count"`
+	ForkedFrom     *QuizOrigin   `json:"forked_from,omitempty"`
+	IsHidden       bool          `json:"-"`
+}
+
+// QuizOrigin attributes a forked quiz to the quiz it was copied from. Title and author are empty
+// when the original has since been hidden or is no longer visible.
+type QuizOrigin struct {
+	QuizID         uuid.UUID `json:"quiz_id"`
+	Title          string    `json:"title,omitempty"`
+	AuthorID       string    `json:"author_id,omitempty"`
+	AuthorUsername string    `json:"author_username,omitempty"`
 }
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Report struct {
+	ID               uuid.UUID  `json:"id"`
+	ReporterID       uuid.UUID  `json:"reporter_id"`
+	ReporterUsername string     `json:"reporter_username,omitempty"`
+	TargetType       string     `json:"target_type"`
+	TargetID         uuid.UUID  `json:"target_id"`
+	Reason           string     `json:"reason"`
+	Details          string     `json:"details"`
+	Status           string     `json:"status"`
+	ResolutionAction *string    `json:"resolution_action,omitempty"`
+	ResolutionNote   *string    `json:"resolution_note,omitempty"`
+	ResolvedBy       *uuid.UUID `json:"resolved_by,omitempty"`
+	ResolvedAt       *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	OpenReports      int        `json:"open_reports_for_target,omitempty"`
+}
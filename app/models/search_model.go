@@ -0,0 +1,57 @@
+package models
+
+import (
+	"github.com/google/uuid"
+)
+
+const (
+	SearchTypeUsers       = "users"
+	SearchTypeQuizzes     = "quizzes"
+	SearchTypeStudyGroups = "study_groups"
+)
+
+type SearchFilter struct {
+	Query      string
+	Types      []string
+	Difficulty string
+	ViewerID   uuid.UUID
+	Limit      int
+	Offset     int
+}
+
+type UserSearchResult struct {
+	ID            uuid.UUID `json:"id"`
+	Username      string    `json:"username"`
+	ImageURL      *string   `json:"image_url,omitempty"`
+	FollowerCount int       `json:"follower_count"`
+	Rank          float64   `json:"rank"`
+}
+
+type QuizSearchResult struct {
+	ID              uuid.UUID `json:"id"`
+	Title           string    `json:"title"`
+	Description     string    `json:"description"`
+	Difficulty      string    `json:"difficulty"`
+	CreatedBy       uuid.UUID `json:"created_by"`
+	CreatorUsername string    `json:"creator_username"`
+	TotalQuestions  int       `json:"total_questions"`
+	Headline        string    `json:"headline,omitempty"`
+	Rank            float64   `json:"rank"`
+}
+
+type StudyGroupSearchResult struct {
+	ID          uuid.UUID `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	SubjectTags []string  `json:"subject_tags"`
+	MemberCount int       `json:"member_count"`
+	MaxMember   int       `json:"max_member"`
+	Rank        float64   `json:"rank"`
+}
+
+type SearchResults struct {
+	Query       string                   `json:"query"`
+	Users       []UserSearchResult       `json:"users,omitempty"`
+	Quizzes     []QuizSearchResult       `json:"quizzes,omitempty"`
+	StudyGroups []StudyGroupSearchResult `json:"study_groups,omitempty"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type QuizAnalytics struct {
+	QuizID         uuid.UUID `json:"quiz_id"`
+	Title          string    `json:"title"`
+	Attempts       int       `json:"attempts"`
+	Participants   int       `json:"participants"`
+	AverageScore   float64   `json:"average_score"`
+	AveragePercent float64   `json:"average_percent"`
+	CompletionRate float64   `json:"completion_rate"`
+}
+
+type QuestionDifficulty struct {
+	QuestionID   uuid.UUID `json:"question_id"`
+	QuizID       uuid.UUID `json:"quiz_id"`
+	QuestionText string    `json:"question_text"`
+	Answers      int       `json:"answers"`
+	CorrectRate  float64   `json:"correct_rate"`
+}
+
+type MemberTrendPoint struct {
+	Period         time.Time `json:"period"`
+	Attempts       int       `json:"attempts"`
+	AveragePercent float64   `json:"average_percent"`
+}
+
+type MemberTrend struct {
+	UserID   uuid.UUID          `json:"user_id"`
+	Username string             `json:"username"`
+	Points   []MemberTrendPoint `json:"points"`
+}
+
+type InactiveMember struct {
+	UserID        uuid.UUID  `json:"user_id"`
+	Username      string     `json:"username"`
+	JoinedAt      time.Time  `json:"joined_at"`
+	LastAttemptAt *time.Time `json:"last_attempt_at,omitempty"`
+}
+
+type StudyGroupAnalytics struct {
+	GroupID          uuid.UUID            `json:"group_id"`
+	From             time.Time            `json:"from"`
+	To               time.Time            `json:"to"`
+	MemberCount      int                  `json:"member_count"`
+	Quizzes          []QuizAnalytics      `json:"quizzes"`
+	HardestQuestions []QuestionDifficulty `json:"hardest_questions"`
+	MemberTrends     []MemberTrend        `json:"member_trends"`
+	InactiveMembers  []InactiveMember     `json:"inactive_members"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StudyGroupSummary is the public view of a study group used by discovery; it never carries the invite code
+type StudyGroupSummary struct {
+	ID              uuid.UUID  `json:"id"`
+	Name            string     `json:"name"`
+	Description     *string    `json:"description,omitempty"`
+	SubjectTags     []string   `json:"subject_tags"`
+	MemberCount     int        `json:"member_count"`
+	MaxMember       int        `json:"max_member"`
+	CreatedBy       uuid.UUID  `json:"created_by"`
+	CreatedAt       time.Time  `json:"created_at"`
+	LastActivityAt  *time.Time `json:"last_activity_at,omitempty"`
+	FollowedMembers int        `json:"followed_members,omitempty"`
+}
+
+type StudyGroupDiscoveryFilter struct {
+	Query            string
+	Tags             []string
+	MinMembers       *int
+	MaxMembers       *int
+	HasSpace         bool
+	ActiveWithinDays int
+	Sort             string
+	Limit            int
+	Offset           int
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type StudyGroupInvite struct {
+	ID        uuid.UUID  `json:"id"`
+	GroupID   uuid.UUID  `json:"group_id"`
+	Code      string     `json:"code"`
+	CreatedBy uuid.UUID  `json:"created_by"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	MaxUses   *int       `json:"max_uses,omitempty"`
+	UseCount  int        `json:"use_count"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// IsUsable reports whether the invite can still be redeemed at the given time
+func (i *StudyGroupInvite) IsUsable(now time.Time) bool {
+	if i.RevokedAt != nil {
+		return false
+	}
+	if i.ExpiresAt != nil && !now.Before(*i.ExpiresAt) {
+		return false
+	}
+	if i.MaxUses != nil && i.UseCount >= *i.MaxUses {
+		return false
+	}
+	return true
+}
+
+// StudyGroupInviteAudit records which invite a member used to join a group
+type StudyGroupInviteAudit struct {
+	UserID     uuid.UUID  `json:"user_id"`
+	Username   string     `json:"username"`
+	JoinedAt   time.Time  `json:"joined_at"`
+	InviteID   *uuid.UUID `json:"invite_id,omitempty"`
+	InviteCode *string    `json:"invite_code,omitempty"`
+}
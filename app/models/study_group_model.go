@@ -10,10 +10,11 @@ type StudyGroup struct {
 	ID          uuid.UUID `json:"id" `
 	Name        string    `json:"name" validate:"required,lte=100"`
 	Description *string   `json:"description,omitempty" validate:"lte=500"`
-	InviteCode  *string   `json:"invite_code"`
+	InviteCode  *string   `json:"invite_code,omitempty"`
 	MemberCount int       `json:"member_count" `
 	MaxMember   int       `json:"max_member" validate:"required,min=1"`
 	IsPrivate   bool      `json:"is_private"`
+	SubjectTags []string  `json:"subject_tags" validate:"max=10,dive,lte=50"`
 	CreatedBy   uuid.UUID `json:"created_by" `
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" `
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// TwoFactorStatus describes the caller's 2FA enrollment. Required is set for admins while the
+// admin_require_2fa setting is on.
+type TwoFactorStatus struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	Pending                bool       `json:"pending"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+	Required               bool       `json:"required"`
+}
+
+// TwoFactorCode is the second step of a sign-in or a confirmation for 2FA changes; one of the two is set
+type TwoFactorCode struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
@@ -8,22 +8,73 @@ import (
 )
 
 type User struct {
-	ID             uuid.UUID      `json:"id" validate:"required,uuid"`
-	Username       string         `json:"username" validate:"required,lte=50"`
-	Email          string         `json:"email" validate:"required,email,lte=255"`
-	PasswordHash   string         `json:"password_hash,omitempty" validate:"required,lte=255"`
-	ExpPoints      string         `json:"exp_point,omitempty" validate:"omitempty,lte=25"`
-	UserRole       string         `json:"user_role" validate:"required,lte=25"`
-	ImageURL       sql.NullString `json:"image_url,omitempty" validate:"omitempty,lte=255"`
-	CreatedAt      time.Time      `json:"created_at"`
-	UpdatedAt      time.Time      `json:"updated_at"`
-	FollowerCount  *int           `json:"follower_count,omitempty"`
-	FollowingCount *int           `json:"following_count,omitempty"`
+	ID                  uuid.UUID      `json:"id" validate:"required,uuid"`
+	Username            string         `json:"username" validate:"required,lte=50"`
+	Email               string         `json:"email" validate:"required,email,lte=255"`
+	PasswordHash        string         `json:"password_hash,omitempty" validate:"required,lte=255"`
+	ExpPoints           string         `json:"exp_point,omitempty" validate:"omitempty,lte=25"`
+	UserRole            string         `json:"user_role" validate:"required,lte=25"`
+	ImageURL            sql.NullString `json:"image_url,omitempty" validate:"omitempty,lte=255"`
+	Bio                 *string        `json:"bio,omitempty"`
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+	FollowerCount       *int           `json:"follower_count,omitempty"`
+	FollowingCount      *int           `json:"following_count,omitempty"`
+	SuspendedAt         *time.Time     `json:"suspended_at,omitempty"`
+	SuspendedUntil      *time.Time     `json:"suspended_until,omitempty"`
+	EmailVerifiedAt     *time.Time     `json:"email_verified_at,omitempty"`
+	DeletionScheduledAt *time.Time     `json:"deletion_scheduled_at,omitempty"`
+	TwoFactorEnabledAt  *time.Time     `json:"two_factor_enabled_at,omitempty"`
+	IsFollowing         *bool          `json:"is_following,omitempty"`
+	FollowsYou          *bool          `json:"follows_you,omitempty"`
+	IsMutual            *bool          `json:"is_mutual,omitempty"`
+	MutualCount         *int           `json:"mutual_follower_count,omitempty"`
 }
 
+// IsSuspended reports whether a moderator suspension is in effect at now. A nil SuspendedUntil means indefinitely.
+func (u *User) IsSuspended(now time.Time) bool {
+	if u.SuspendedAt == nil {
+		return false
+	}
+	return u.SuspendedUntil == nil || now.Before(*u.SuspendedUntil)
+}
+
+// RecommendedUser is a suggested account to follow together with the signals that picked it
 type RecommendedUser struct {
-	ID            uuid.UUID `json:"id"`
-	Username      string    `json:"username"`
-	Email         string    `json:"email"`
-	FollowerCount int       `json:"follower_count"`
+	ID            uuid.UUID  `json:"id"`
+	Username      string     `json:"username"`
+	ImageURL      *string    `json:"image_url,omitempty"`
+	FollowerCount int        `json:"follower_count"`
+	FollowsYou    bool       `json:"follows_you"`
+	MutualFriends int        `json:"mutual_friends"`
+	SharedGroups  int        `json:"shared_groups"`
+	SharedQuizzes int        `json:"shared_quizzes"`
+	LastActiveAt  *time.Time `json:"last_active_at,omitempty"`
+	Score         float64    `json:"score"`
+	Reasons       []string   `json:"reasons"`
+}
+
+// FollowListEntry is one row of a followers or following list. The flags are relative to the caller.
+type FollowListEntry struct {
+	ID          uuid.UUID `json:"id"`
+	Username    string    `json:"username"`
+	ImageURL    *string   `json:"image_url,omitempty"`
+	FollowedAt  time.Time `json:"followed_at"`
+	IsFollowing bool      `json:"is_following"`
+	FollowsYou  bool      `json:"follows_you"`
+	IsMutual    bool      `json:"is_mutual"`
+	IsYou       bool      `json:"is_you"`
+}
+
+type BlockedUser struct {
+	ID        uuid.UUID `json:"id"`
+	Username  string    `json:"username"`
+	ImageURL  *string   `json:"image_url,omitempty"`
+	BlockedAt time.Time `json:"blocked_at"`
+}
+
+// ExportFile is one JSON document of a personal data export
+type ExportFile struct {
+	Name string
+	Data []byte
 }
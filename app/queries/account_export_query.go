@@ -0,0 +1,77 @@
+package queries
+
+import (
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/google/uuid"
+)
+
+// exportSections builds each file of the personal data export as a single JSON document from $1 (the user)
+var exportSections = []struct {
+	File  string
+	Query string
+}{
+	{"profile.json", `SELECT row_to_json(t) FROM (
+		SELECT uid AS id, username, email, user_role, bio, image_url, created_at, updated_at, email_verified_at, deletion_scheduled_at
+		FROM users WHERE uid = $1
+	) t`},
+	{"quizzes.json", `SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]') FROM (
+		SELECT q.id, q.title, q.description, q.difficulty_level, q.time_limit, q.subject, q.tags, q.visibility,
+			q.forked_from, q.study_group_id, q.created_at,
+			COALESCE((
+				SELECT json_agg(json_build_object(
+					'id', qq.id,
+					'question_text', qq.question_text,
+					'explanation', qq.explanation,
+					'options', COALESCE((
+						SELECT json_agg(json_build_object('id', qo.id, 'content', qo.content, 'is_correct', qo.is_correct) ORDER BY qo.created_at, qo.id)
+						FROM quiz_options qo WHERE qo.question_id = qq.id
+					), '[]')
+				) ORDER BY qq.created_at, qq.id)
+				FROM quiz_questions qq WHERE qq.quiz_id = q.id
+			), '[]') AS questions
+		FROM quizzes q WHERE q.created_by = $1
+	) t`},
+	{"attempts.json", `SELECT COALESCE(json_agg(t ORDER BY t.submitted_at), '[]') FROM (
+		SELECT a.id, a.quiz_id, qz.title AS quiz_title, a.score, a.total_questions, a.is_completed, a.submitted_at,
+			COALESCE((
+				SELECT json_agg(json_build_object('question_id', aa.question_id, 'selected_option_id', aa.selected_option_id))
+				FROM attempts_quiz_answer aa WHERE aa.attempt_id = a.id
+			), '[]') AS answers
+		FROM attempts_quiz a
+		LEFT JOIN quizzes qz ON qz.id = a.quiz_id
+		WHERE a.user_id = $1
+	) t`},
+	{"comments.json", `SELECT COALESCE(json_agg(t ORDER BY t.created_at), '[]') FROM (
+		SELECT c.id, c.quiz_id, c.parent_id, c.content, c.created_at, c.updated_at
+		FROM comments c WHERE c.commenter_by = $1
+	) t`},
+	{"follows.json", `SELECT json_build_object(
+		'followers', COALESCE((
+			SELECT json_agg(json_build_object('user_id', u.uid, 'username', u.username, 'followed_at', s.followed_at) ORDER BY s.followed_at)
+			FROM socials s JOIN users u ON u.uid = s.follower_id WHERE s.following = $1
+		), '[]'),
+		'following', COALESCE((
+			SELECT json_agg(json_build_object('user_id', u.uid, 'username', u.username, 'followed_at', s.followed_at) ORDER BY s.followed_at)
+			FROM socials s JOIN users u ON u.uid = s.following WHERE s.follower_id = $1
+		), '[]')
+	)`},
+	{"study_groups.json", `SELECT COALESCE(json_agg(t ORDER BY t.joined_at), '[]') FROM (
+		SELECT g.id, g.name, g.description, m.role, m.joined_at, g.created_by = $1 AS is_owner
+		FROM study_group_member m
+		JOIN study_group g ON g.id = m.group_id
+		WHERE m.user_id = $1
+	) t`},
+}
+
+// ExportUserData returns every file of the user's data export
+func (q *UserQueries) ExportUserData(userID uuid.UUID) ([]models.ExportFile, error) {
+	res := make([]models.ExportFile, 0, len(exportSections))
+	for _, s := range exportSections {
+		var doc []byte
+		if err := q.DB.QueryRow(s.Query, userID).Scan(&doc); err != nil {
+			return nil, err
+		}
+		res = append(res, models.ExportFile{Name: s.File, Data: doc})
+	}
+	return res, nil
+}
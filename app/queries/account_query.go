@@ -0,0 +1,121 @@
+package queries
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/google/uuid"
+)
+
+var ErrNoDeletionScheduled = errors.New("no account deletion is scheduled")
+
+// ScheduleDeletion marks the account for deletion at the given time and signs it out everywhere.
+// With anonymize, quizzes other people depend on are handed to the deleted-user placeholder instead of being removed.
+func (q *UserQueries) ScheduleDeletion(userID uuid.UUID, anonymize bool, at time.Time) ([]uuid.UUID, error) {
+	tx, err := q.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE users SET deletion_scheduled_at = $1, anonymize_on_delete = $2 WHERE uid = $3`, at, anonymize, userID); err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query(`UPDATE user_sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL RETURNING id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	revoked := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		revoked = append(revoked, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return revoked, tx.Commit()
+}
+
+func (q *UserQueries) CancelDeletion(userID uuid.UUID) error {
+	res, err := q.DB.Exec(`UPDATE users SET deletion_scheduled_at = NULL, anonymize_on_delete = FALSE WHERE uid = $1 AND deletion_scheduled_at IS NOT NULL`, userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNoDeletionScheduled
+	}
+	return nil
+}
+
+// GetDueDeletions returns accounts whose grace period is over
+func (q *UserQueries) GetDueDeletions(limit int) ([]uuid.UUID, error) {
+	rows, err := q.DB.Query(`SELECT uid FROM users WHERE deletion_scheduled_at <= NOW() ORDER BY deletion_scheduled_at LIMIT $1`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		res = append(res, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// PurgeUser deletes an account whose deletion is due; everything it owns goes with it through the
+// cascading foreign keys. When anonymization was requested, quizzes that other users attempted, forked,
+// collected or that belong to a study group are first moved to the deleted-user placeholder.
+// It returns false when the deletion was cancelled in the meantime.
+func (q *UserQueries) PurgeUser(userID uuid.UUID) (bool, error) {
+	tx, err := q.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var anonymize bool
+	err = tx.QueryRow(`SELECT anonymize_on_delete FROM users WHERE uid = $1 AND deletion_scheduled_at <= NOW() FOR UPDATE`, userID).Scan(&anonymize)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if anonymize {
+		_, err := tx.Exec(`UPDATE quizzes q SET created_by = $2, share_token = NULL
+			WHERE q.created_by = $1 AND (
+				q.study_group_id IS NOT NULL
+				OR EXISTS (SELECT 1 FROM attempts_quiz a WHERE a.quiz_id = q.id AND a.user_id <> $1)
+				OR EXISTS (SELECT 1 FROM quizzes f WHERE f.forked_from = q.id AND f.created_by <> $1)
+				OR EXISTS (SELECT 1 FROM collection_items ci JOIN collections c ON c.id = ci.collection_id WHERE ci.quiz_id = q.id AND c.owner_id <> $1)
+				OR EXISTS (SELECT 1 FROM bookmarks b WHERE b.quiz_id = q.id AND b.user_id <> $1)
+			)`, userID, utils.DeletedUserID)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM users WHERE uid = $1`, userID); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
@@ -0,0 +1,201 @@
+package queries
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type AdminQueries struct {
+	DB *sql.DB
+}
+
+var (
+	ErrAdminUserNotFound = errors.New("user not found")
+	ErrLastAdmin         = errors.New("cannot remove the last admin")
+	ErrSuspendAdmin      = errors.New("admins cannot be suspended, change their role first")
+	ErrContentNotFound   = errors.New("content not found")
+)
+
+// ListUsers searches accounts by username or email, optionally narrowed to a role or to suspended accounts
+func (q *AdminQueries) ListUsers(f models.AdminUserFilter) ([]models.AdminUser, error) {
+	query := `
+	SELECT u.uid, u.username, u.email, u.user_role, u.image_url, u.created_at, u.email_verified_at, u.suspended_at, u.suspended_until,
+		(SELECT COUNT(*) FROM quizzes qz WHERE qz.created_by = u.uid) AS quiz_count,
+		(SELECT COUNT(*) FROM reports r WHERE r.target_type = 'user' AND r.target_id = u.uid AND r.status = 'open') AS open_reports
+	FROM users u
+	WHERE ($1 = '' OR u.username ILIKE '%' || $1 || '%' OR u.email ILIKE '%' || $1 || '%')
+	  AND ($2 = '' OR u.user_role = $2)
+	  AND ($3::boolean IS NULL OR (u.suspended_at IS NOT NULL AND (u.suspended_until IS NULL OR u.suspended_until > NOW())) = $3)
+	ORDER BY u.created_at DESC, u.uid
+	LIMIT $4 OFFSET $5`
+	rows, err := q.DB.Query(query, f.Query, f.Role, f.Suspended, f.Limit, f.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.AdminUser{}
+	for rows.Next() {
+		var u models.AdminUser
+		var imageURL sql.NullString
+		var verifiedAt, suspendedAt, suspendedUntil sql.NullTime
+		if err := rows.Scan(&u.ID, &u.Username, &u.Email, &u.UserRole, &imageURL, &u.CreatedAt, &verifiedAt, &suspendedAt, &suspendedUntil,
+			&u.QuizCount, &u.OpenReports); err != nil {
+			return nil, err
+		}
+		if imageURL.Valid {
+			u.ImageURL = &imageURL.String
+		}
+		if verifiedAt.Valid {
+			u.EmailVerifiedAt = &verifiedAt.Time
+		}
+		if suspendedAt.Valid {
+			u.SuspendedAt = &suspendedAt.Time
+		}
+		if suspendedUntil.Valid {
+			u.SuspendedUntil = &suspendedUntil.Time
+		}
+		res = append(res, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// SetUserRole changes an account's role. The last remaining admin cannot be demoted.
+func (q *AdminQueries) SetUserRole(userID uuid.UUID, role string) error {
+	tx, err := q.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// lock the admin rows so two concurrent demotions cannot both pass the check
+	if _, err := tx.Exec(`SELECT uid FROM users WHERE user_role = 'admin' FOR UPDATE`); err != nil {
+		return err
+	}
+	var current string
+	if err := tx.QueryRow(`SELECT user_role FROM users WHERE uid = $1`, userID).Scan(&current); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrAdminUserNotFound
+		}
+		return err
+	}
+	if current == utils.RoleAdmin && role != utils.RoleAdmin {
+		var admins int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM users WHERE user_role = 'admin'`).Scan(&admins); err != nil {
+			return err
+		}
+		if admins <= 1 {
+			return ErrLastAdmin
+		}
+	}
+	if _, err := tx.Exec(`UPDATE users SET user_role = $1, updated_at = NOW() WHERE uid = $2`, role, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SuspendUser suspends the account until the given time (nil is indefinite) and revokes its sessions.
+// The ids of the revoked sessions are returned.
+func (q *AdminQueries) SuspendUser(userID uuid.UUID, until *time.Time) ([]uuid.UUID, error) {
+	tx, err := q.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	revoked, err := suspendUser(tx, userID, until)
+	if err != nil {
+		return nil, err
+	}
+	return revoked, tx.Commit()
+}
+
+// suspendUser is shared by the admin panel and report moderation so both refuse to suspend admins
+func suspendUser(tx *sql.Tx, userID uuid.UUID, until *time.Time) ([]uuid.UUID, error) {
+	// the role check and the demotion guard in SetUserRole together keep at least one usable admin
+	var role sql.NullString
+	if err := tx.QueryRow(`SELECT user_role FROM users WHERE uid = $1 FOR UPDATE`, userID).Scan(&role); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrAdminUserNotFound
+		}
+		return nil, err
+	}
+	if role.String == utils.RoleAdmin {
+		return nil, ErrSuspendAdmin
+	}
+
+	if _, err := tx.Exec(`UPDATE users SET suspended_at = NOW(), suspended_until = $2 WHERE uid = $1`, userID, until); err != nil {
+		return nil, err
+	}
+	return revokeUserSessions(tx, userID)
+}
+
+func (q *AdminQueries) UnsuspendUser(userID uuid.UUID) error {
+	res, err := q.DB.Exec(`UPDATE users SET suspended_at = NULL, suspended_until = NULL WHERE uid = $1`, userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrAdminUserNotFound
+	}
+	return nil
+}
+
+// DeleteContent permanently removes a quiz, comment or study group. Reports on it are closed as actioned.
+func (q *AdminQueries) DeleteContent(adminID uuid.UUID, targetType string, targetID uuid.UUID) error {
+	if targetType == utils.ReportTargetUser {
+		return fmt.Errorf("users cannot be deleted as content")
+	}
+	t, ok := reportTargetTables[targetType]
+	if !ok {
+		return fmt.Errorf("invalid target type")
+	}
+
+	tx, err := q.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(fmt.Sprintf(`DELETE FROM %s WHERE %s = $1`, t[0], t[1]), targetID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrContentNotFound
+	}
+	if _, err := tx.Exec(`UPDATE reports SET status = $1, resolution_action = $2, resolved_by = $3, resolved_at = NOW()
+		WHERE status = 'open' AND target_type = $4 AND target_id = $5`,
+		utils.ReportStatusActioned, utils.ModerationDeleteContent, adminID, targetType, targetID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// BootstrapAdmins promotes the verified accounts with the given emails to admin and returns how many
+// were changed. It is how the first admin is created, since sign-up only creates plain users.
+func (q *AdminQueries) BootstrapAdmins(emails []string) (int64, error) {
+	res, err := q.DB.Exec(`UPDATE users SET user_role = $2, updated_at = NOW()
+		WHERE LOWER(email) = ANY($1) AND user_role <> $2 AND email_verified_at IS NOT NULL`,
+		pq.Array(emails), utils.RoleAdmin)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,137 @@
+package queries
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type APIKeyQueries struct {
+	DB *sql.DB
+}
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrTooManyAPIKeys = errors.New("too many active api keys, revoke one first")
+)
+
+func (q *APIKeyQueries) CreateAPIKey(userID uuid.UUID, name, prefix, keyHash string, scopes []string, expiresAt *time.Time) (*models.APIKey, error) {
+	tx, err := q.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// serialize key creation per user so the limit holds under concurrent requests
+	if _, err := tx.Exec(`SELECT uid FROM users WHERE uid = $1 FOR UPDATE`, userID); err != nil {
+		return nil, err
+	}
+	var active int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM api_keys
+		WHERE user_id = $1 AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > NOW())`, userID).Scan(&active)
+	if err != nil {
+		return nil, err
+	}
+	if active >= utils.MaxAPIKeysPerUser {
+		return nil, ErrTooManyAPIKeys
+	}
+
+	k := &models.APIKey{Name: name, Prefix: prefix, Scopes: scopes, ExpiresAt: expiresAt}
+	err = tx.QueryRow(`INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at`,
+		userID, name, prefix, keyHash, pq.Array(scopes), expiresAt).Scan(&k.ID, &k.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return k, tx.Commit()
+}
+
+func (q *APIKeyQueries) GetAPIKeys(userID uuid.UUID) ([]models.APIKey, error) {
+	rows, err := q.DB.Query(`SELECT id, name, prefix, scopes, created_at, last_used_at, last_used_ip, expires_at, revoked_at
+		FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.APIKey{}
+	for rows.Next() {
+		var k models.APIKey
+		var lastUsedAt, expiresAt, revokedAt sql.NullTime
+		var lastUsedIP sql.NullString
+		if err := rows.Scan(&k.ID, &k.Name, &k.Prefix, pq.Array(&k.Scopes), &k.CreatedAt, &lastUsedAt, &lastUsedIP, &expiresAt, &revokedAt); err != nil {
+			return nil, err
+		}
+		if lastUsedAt.Valid {
+			k.LastUsedAt = &lastUsedAt.Time
+		}
+		if lastUsedIP.Valid {
+			k.LastUsedIP = &lastUsedIP.String
+		}
+		if expiresAt.Valid {
+			k.ExpiresAt = &expiresAt.Time
+		}
+		if revokedAt.Valid {
+			k.RevokedAt = &revokedAt.Time
+		}
+		res = append(res, k)
+	}
+	return res, rows.Err()
+}
+
+func (q *APIKeyQueries) RevokeAPIKey(userID, keyID uuid.UUID) error {
+	res, err := q.DB.Exec(`UPDATE api_keys SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`, keyID, userID)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// GetAPIKeyPrincipal resolves an active, unexpired key by its hash. It returns nil, nil for unknown keys
+// and for keys of accounts scheduled for deletion, which stay signed out until the deletion is cancelled.
+func (q *APIKeyQueries) GetAPIKeyPrincipal(keyHash string) (*models.APIKeyPrincipal, error) {
+	p := &models.APIKeyPrincipal{}
+	var role sql.NullString
+	var lastUsedAt, suspendedAt, suspendedUntil sql.NullTime
+	err := q.DB.QueryRow(`SELECT k.id, k.user_id, k.scopes, k.last_used_at, u.email, u.user_role, u.suspended_at, u.suspended_until
+		FROM api_keys k
+		JOIN users u ON u.uid = k.user_id
+		WHERE k.key_hash = $1 AND k.revoked_at IS NULL AND (k.expires_at IS NULL OR k.expires_at > NOW())
+			AND u.deletion_scheduled_at IS NULL`, keyHash).
+		Scan(&p.KeyID, &p.UserID, pq.Array(&p.Scopes), &lastUsedAt, &p.Email, &role, &suspendedAt, &suspendedUntil)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	p.UserRole = role.String
+	if lastUsedAt.Valid {
+		p.LastUsedAt = &lastUsedAt.Time
+	}
+	if suspendedAt.Valid {
+		p.SuspendedAt = &suspendedAt.Time
+	}
+	if suspendedUntil.Valid {
+		p.SuspendedUntil = &suspendedUntil.Time
+	}
+	return p, nil
+}
+
+func (q *APIKeyQueries) TouchAPIKey(keyID uuid.UUID, ip string) error {
+	_, err := q.DB.Exec(`UPDATE api_keys SET last_used_at = NOW(), last_used_ip = $2 WHERE id = $1`, keyID, ip)
+	return err
+}
@@ -0,0 +1,454 @@
+package queries
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrCollectionNotFound = errors.New("collection not found")
+	ErrQuizUnavailable    = errors.New("quiz not found")
+	ErrInvalidOrder       = errors.New("order must list every quiz in the collection exactly once")
+	ErrEmptyCollection    = errors.New("collection has no quizzes")
+)
+
+type CollectionQueries struct {
+	DB *sql.DB
+}
+
+const quizSummaryColumns = `q.id, q.title, q.difficulty_level, q.created_by,
+	COALESCE((SELECT COUNT(*) FROM quiz_questions qq WHERE qq.quiz_id = q.id), 0)`
+
+func (q *CollectionQueries) AddBookmark(userID, quizID uuid.UUID) error {
+	query := `
+	INSERT INTO bookmarks (user_id, quiz_id)
+	SELECT $1, q.id FROM quizzes q WHERE q.id = $2 AND q.is_hidden = FALSE AND ` + quizVisibleTo("q", "$1::uuid") + `
+	ON CONFLICT DO NOTHING`
+	res, err := q.DB.Exec(query, userID, quizID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		var exists bool
+		existsQuery := `SELECT EXISTS(SELECT 1 FROM quizzes q WHERE q.id = $1 AND q.is_hidden = FALSE AND ` + quizVisibleTo("q", "$2::uuid") + `)`
+		if err := q.DB.QueryRow(existsQuery, quizID, userID).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return ErrQuizUnavailable
+		}
+	}
+	return nil
+}
+
+func (q *CollectionQueries) RemoveBookmark(userID, quizID uuid.UUID) error {
+	res, err := q.DB.Exec(`DELETE FROM bookmarks WHERE user_id = $1 AND quiz_id = $2`, userID, quizID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errors.New("bookmark not found")
+	}
+	return nil
+}
+
+func (q *CollectionQueries) GetBookmarks(userID uuid.UUID, limit, offset int) ([]models.Bookmark, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	query := `
+	SELECT ` + quizSummaryColumns + `, b.created_at
+	FROM bookmarks b
+	JOIN quizzes q ON q.id = b.quiz_id
+	WHERE b.user_id = $1 AND q.is_hidden = FALSE AND ` + quizVisibleTo("q", "$1::uuid") + `
+	ORDER BY b.created_at DESC
+	LIMIT $2 OFFSET $3`
+	rows, err := q.DB.Query(query, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.Bookmark{}
+	for rows.Next() {
+		var b models.Bookmark
+		if err := rows.Scan(&b.Quiz.ID, &b.Quiz.Title, &b.Quiz.Difficulty, &b.Quiz.CreatedBy, &b.Quiz.TotalQuestions, &b.CreatedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+const collectionColumns = `c.id, c.owner_id, c.name, c.description, c.is_public, c.share_token, c.created_at, c.updated_at,
+	(SELECT COUNT(*) FROM collection_items ci WHERE ci.collection_id = c.id)`
+
+func scanCollection(row interface{ Scan(...interface{}) error }) (*models.Collection, error) {
+	var c models.Collection
+	var token sql.NullString
+	if err := row.Scan(&c.ID, &c.OwnerID, &c.Name, &c.Description, &c.IsPublic, &token, &c.CreatedAt, &c.UpdatedAt, &c.ItemCount); err != nil {
+		return nil, err
+	}
+	if token.Valid {
+		c.ShareToken = &token.String
+	}
+	return &c, nil
+}
+
+func (q *CollectionQueries) CreateCollection(c *models.Collection) (*models.Collection, error) {
+	query := `
+	INSERT INTO collections (owner_id, name, description, is_public)
+	VALUES ($1, $2, $3, $4)
+	RETURNING id`
+	var id uuid.UUID
+	if err := q.DB.QueryRow(query, c.OwnerID, c.Name, c.Description, c.IsPublic).Scan(&id); err != nil {
+		return nil, err
+	}
+	return q.GetCollection(id)
+}
+
+func (q *CollectionQueries) UpdateCollection(c *models.Collection) error {
+	query := `UPDATE collections SET name = $1, description = $2, is_public = $3, updated_at = NOW() WHERE id = $4 AND owner_id = $5`
+	res, err := q.DB.Exec(query, c.Name, c.Description, c.IsPublic, c.ID, c.OwnerID)
+	if err != nil {
+		return err
+	}
+	return collectionAffected(res)
+}
+
+func (q *CollectionQueries) DeleteCollection(id, ownerID uuid.UUID) error {
+	res, err := q.DB.Exec(`DELETE FROM collections WHERE id = $1 AND owner_id = $2`, id, ownerID)
+	if err != nil {
+		return err
+	}
+	return collectionAffected(res)
+}
+
+func collectionAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrCollectionNotFound
+	}
+	return nil
+}
+
+// GetCollection returns the collection without items, or nil when it does not exist
+func (q *CollectionQueries) GetCollection(id uuid.UUID) (*models.Collection, error) {
+	c, err := scanCollection(q.DB.QueryRow(`SELECT `+collectionColumns+` FROM collections c WHERE c.id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+func (q *CollectionQueries) GetCollectionByShareToken(token string) (*models.Collection, error) {
+	c, err := scanCollection(q.DB.QueryRow(`SELECT `+collectionColumns+` FROM collections c WHERE c.share_token = $1`, token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetCollectionsByOwner lists a user's collections; private ones are only included when publicOnly is false
+func (q *CollectionQueries) GetCollectionsByOwner(ownerID uuid.UUID, publicOnly bool, limit, offset int) ([]models.Collection, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	query := `SELECT ` + collectionColumns + `
+	FROM collections c
+	WHERE c.owner_id = $1 AND ($2 = FALSE OR c.is_public = TRUE)
+	ORDER BY c.updated_at DESC
+	LIMIT $3 OFFSET $4`
+	rows, err := q.DB.Query(query, ownerID, publicOnly, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.Collection{}
+	for rows.Next() {
+		c, err := scanCollection(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetCollectionItems returns the collection's quizzes in order, skipping quizzes hidden by moderators
+// and those the viewer (uuid.Nil for anonymous) may not see
+func (q *CollectionQueries) GetCollectionItems(collectionID, viewer uuid.UUID) ([]models.CollectionItem, error) {
+	query := `
+	SELECT ci.position, ` + quizSummaryColumns + `, ci.added_at
+	FROM collection_items ci
+	JOIN quizzes q ON q.id = ci.quiz_id
+	WHERE ci.collection_id = $1 AND q.is_hidden = FALSE AND ` + quizVisibleTo("q", "$2::uuid") + `
+	ORDER BY ci.position`
+	rows, err := q.DB.Query(query, collectionID, viewer)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.CollectionItem{}
+	for rows.Next() {
+		var it models.CollectionItem
+		if err := rows.Scan(&it.Position, &it.Quiz.ID, &it.Quiz.Title, &it.Quiz.Difficulty, &it.Quiz.CreatedBy, &it.Quiz.TotalQuestions, &it.AddedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, it)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// AddCollectionItem appends a quiz to the end of the owner's collection
+func (q *CollectionQueries) AddCollectionItem(collectionID, ownerID, quizID uuid.UUID) error {
+	tx, err := q.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockOwnedCollection(tx, collectionID, ownerID); err != nil {
+		return err
+	}
+	var exists bool
+	existsQuery := `SELECT EXISTS(SELECT 1 FROM quizzes q WHERE q.id = $1 AND q.is_hidden = FALSE AND ` + quizVisibleTo("q", "$2::uuid") + `)`
+	if err := tx.QueryRow(existsQuery, quizID, ownerID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrQuizUnavailable
+	}
+
+	query := `
+	INSERT INTO collection_items (collection_id, quiz_id, position)
+	SELECT $1, $2, COALESCE(MAX(position), 0) + 1 FROM collection_items WHERE collection_id = $1
+	ON CONFLICT DO NOTHING`
+	if _, err := tx.Exec(query, collectionID, quizID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE collections SET updated_at = NOW() WHERE id = $1`, collectionID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RemoveCollectionItem drops a quiz and closes the gap it leaves in the positions
+func (q *CollectionQueries) RemoveCollectionItem(collectionID, ownerID, quizID uuid.UUID) error {
+	tx, err := q.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockOwnedCollection(tx, collectionID, ownerID); err != nil {
+		return err
+	}
+	var position int
+	err = tx.QueryRow(`DELETE FROM collection_items WHERE collection_id = $1 AND quiz_id = $2 RETURNING position`, collectionID, quizID).Scan(&position)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("quiz is not in this collection")
+		}
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE collection_items SET position = position - 1 WHERE collection_id = $1 AND position > $2`, collectionID, position); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE collections SET updated_at = NOW() WHERE id = $1`, collectionID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ReorderCollection sets positions from the order of quizIDs, which must be a permutation of the collection's items
+func (q *CollectionQueries) ReorderCollection(collectionID, ownerID uuid.UUID, quizIDs []uuid.UUID) error {
+	tx, err := q.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockOwnedCollection(tx, collectionID, ownerID); err != nil {
+		return err
+	}
+
+	var current int
+	var matching int
+	ids := make([]string, len(quizIDs))
+	for i, id := range quizIDs {
+		ids[i] = id.String()
+	}
+	query := `
+	SELECT COUNT(*), COUNT(*) FILTER (WHERE quiz_id = ANY($2::uuid[]))
+	FROM collection_items WHERE collection_id = $1`
+	if err := tx.QueryRow(query, collectionID, pq.Array(ids)).Scan(&current, &matching); err != nil {
+		return err
+	}
+	seen := map[uuid.UUID]bool{}
+	for _, id := range quizIDs {
+		seen[id] = true
+	}
+	if current != len(quizIDs) || matching != current || len(seen) != len(quizIDs) {
+		return ErrInvalidOrder
+	}
+
+	update := `
+	UPDATE collection_items ci SET position = o.ord
+	FROM unnest($2::uuid[]) WITH ORDINALITY AS o(quiz_id, ord)
+	WHERE ci.collection_id = $1 AND ci.quiz_id = o.quiz_id`
+	if _, err := tx.Exec(update, collectionID, pq.Array(ids)); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE collections SET updated_at = NOW() WHERE id = $1`, collectionID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func lockOwnedCollection(tx *sql.Tx, collectionID, ownerID uuid.UUID) error {
+	var id uuid.UUID
+	err := tx.QueryRow(`SELECT id FROM collections WHERE id = $1 AND owner_id = $2 FOR UPDATE`, collectionID, ownerID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return ErrCollectionNotFound
+	}
+	return err
+}
+
+// RotateShareToken gives the collection a new share link, invalidating the old one
+func (q *CollectionQueries) RotateShareToken(collectionID, ownerID uuid.UUID) (string, error) {
+	const maxAttempt = 3
+
+	for i := 0; i < maxAttempt; i++ {
+		token, err := utils.GenerateInviteCode(24)
+		if err != nil {
+			return "", err
+		}
+		res, err := q.DB.Exec(`UPDATE collections SET share_token = $1, updated_at = NOW() WHERE id = $2 AND owner_id = $3`, token, collectionID, ownerID)
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+				continue
+			}
+			return "", err
+		}
+		if err := collectionAffected(res); err != nil {
+			return "", err
+		}
+		return token, nil
+	}
+	return "", errors.New("failed to generate unique share token")
+}
+
+func (q *CollectionQueries) RevokeShareToken(collectionID, ownerID uuid.UUID) error {
+	res, err := q.DB.Exec(`UPDATE collections SET share_token = NULL, updated_at = NOW() WHERE id = $1 AND owner_id = $2`, collectionID, ownerID)
+	if err != nil {
+		return err
+	}
+	return collectionAffected(res)
+}
+
+// StartCollectionRun snapshots the collection's current order into a new run for userID
+func (q *CollectionQueries) StartCollectionRun(collectionID, userID uuid.UUID) (*models.CollectionRun, error) {
+	query := `
+	INSERT INTO collection_runs (collection_id, user_id, quiz_ids)
+	SELECT $1, $2, array_agg(ci.quiz_id ORDER BY ci.position)
+	FROM collection_items ci
+	JOIN quizzes q ON q.id = ci.quiz_id
+	WHERE ci.collection_id = $1 AND q.is_hidden = FALSE AND ` + quizVisibleTo("q", "$2::uuid") + `
+	HAVING COUNT(*) > 0
+	RETURNING id`
+	var id uuid.UUID
+	if err := q.DB.QueryRow(query, collectionID, userID).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrEmptyCollection
+		}
+		return nil, err
+	}
+	return q.GetCollectionRun(id, userID)
+}
+
+// GetCollectionRun returns the run with progress derived from the user's attempts since it started
+func (q *CollectionQueries) GetCollectionRun(runID, userID uuid.UUID) (*models.CollectionRun, error) {
+	query := `
+	SELECT r.id, r.collection_id, r.quiz_ids, r.started_at,
+		ARRAY(
+			SELECT DISTINCT a.quiz_id FROM attempts_quiz a
+			WHERE a.user_id = r.user_id AND a.quiz_id = ANY(r.quiz_ids) AND a.submitted_at >= r.started_at
+		)
+	FROM collection_runs r
+	WHERE r.id = $1 AND r.user_id = $2`
+	var run models.CollectionRun
+	var quizIDs, completed []string
+	err := q.DB.QueryRow(query, runID, userID).Scan(&run.ID, &run.CollectionID, pq.Array(&quizIDs), &run.StartedAt, pq.Array(&completed))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	done := map[uuid.UUID]bool{}
+	run.Completed = make([]uuid.UUID, 0, len(completed))
+	for _, s := range completed {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		done[id] = true
+	}
+	run.QuizIDs = make([]uuid.UUID, 0, len(quizIDs))
+	for _, s := range quizIDs {
+		id, err := uuid.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+		run.QuizIDs = append(run.QuizIDs, id)
+		if done[id] {
+			run.Completed = append(run.Completed, id)
+		} else if run.NextQuizID == nil {
+			next := id
+			run.NextQuizID = &next
+		}
+	}
+	run.Finished = run.NextQuizID == nil
+	return &run, nil
+}
@@ -0,0 +1,188 @@
+package queries
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/google/uuid"
+)
+
+type DiscussionQueries struct {
+	DB *sql.DB
+}
+
+const threadSelect = `
+	SELECT t.id, t.group_id, t.quiz_id, t.title, t.body, t.created_by, u.username,
+		t.is_announcement, t.is_pinned, t.post_count, t.last_post_at, t.created_at, t.updated_at
+	FROM study_group_thread t
+	JOIN users u ON u.uid = t.created_by`
+
+func scanThread(row interface{ Scan(...interface{}) error }) (*models.StudyGroupThread, error) {
+	var t models.StudyGroupThread
+	var quizID uuid.NullUUID
+	if err := row.Scan(&t.ID, &t.GroupID, &quizID, &t.Title, &t.Body, &t.CreatedBy, &t.AuthorUsername,
+		&t.IsAnnouncement, &t.IsPinned, &t.PostCount, &t.LastPostAt, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if quizID.Valid {
+		t.QuizID = &quizID.UUID
+	}
+	return &t, nil
+}
+
+func (q *DiscussionQueries) CreateThread(t *models.StudyGroupThread) (uuid.UUID, error) {
+	query := `INSERT INTO study_group_thread (group_id, quiz_id, title, body, created_by, is_announcement, is_pinned)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	var id uuid.UUID
+	if err := q.DB.QueryRow(query, t.GroupID, t.QuizID, t.Title, t.Body, t.CreatedBy, t.IsAnnouncement, t.IsPinned).Scan(&id); err != nil {
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+func (q *DiscussionQueries) GetThread(groupID, threadID uuid.UUID) (*models.StudyGroupThread, error) {
+	t, err := scanThread(q.DB.QueryRow(threadSelect+` WHERE t.group_id = $1 AND t.id = $2`, groupID, threadID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return t, nil
+}
+
+// GetThreads lists a group's threads with pinned ones first, then by latest activity
+func (q *DiscussionQueries) GetThreads(groupID uuid.UUID, announcementsOnly bool, limit, offset int) ([]models.StudyGroupThread, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	query := threadSelect + ` WHERE t.group_id = $1 AND ($2 = FALSE OR t.is_announcement = TRUE)
+		ORDER BY t.is_pinned DESC, t.last_post_at DESC
+		LIMIT $3 OFFSET $4`
+	rows, err := q.DB.Query(query, groupID, announcementsOnly, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.StudyGroupThread{}
+	for rows.Next() {
+		t, err := scanThread(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetOrCreateQuizThread returns the discussion thread for a quiz assigned to the group, creating it on first use
+func (q *DiscussionQueries) GetOrCreateQuizThread(groupID, quizID, userID uuid.UUID, title string) (*models.StudyGroupThread, error) {
+	_, err := q.DB.Exec(`INSERT INTO study_group_thread (group_id, quiz_id, title, created_by)
+		VALUES ($1, $2, $3, $4) ON CONFLICT (group_id, quiz_id) DO NOTHING`, groupID, quizID, title, userID)
+	if err != nil {
+		return nil, err
+	}
+	return scanThread(q.DB.QueryRow(threadSelect+` WHERE t.group_id = $1 AND t.quiz_id = $2`, groupID, quizID))
+}
+
+func (q *DiscussionQueries) SetThreadPinned(groupID, threadID uuid.UUID, pinned bool) error {
+	res, err := q.DB.Exec(`UPDATE study_group_thread SET is_pinned = $1, updated_at = $2 WHERE group_id = $3 AND id = $4`, pinned, time.Now(), groupID, threadID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errors.New("thread not found")
+	}
+	return nil
+}
+
+func (q *DiscussionQueries) DeleteThread(groupID, threadID uuid.UUID) error {
+	_, err := q.DB.Exec(`DELETE FROM study_group_thread WHERE group_id = $1 AND id = $2`, groupID, threadID)
+	return err
+}
+
+func (q *DiscussionQueries) CreatePost(p *models.StudyGroupPost) (uuid.UUID, error) {
+	tx, err := q.DB.Begin()
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback()
+
+	if p.ParentID != nil {
+		var cnt int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM study_group_post WHERE id = $1 AND thread_id = $2`, *p.ParentID, p.ThreadID).Scan(&cnt); err != nil {
+			return uuid.Nil, err
+		}
+		if cnt == 0 {
+			return uuid.Nil, errors.New("parent post not found in thread")
+		}
+	}
+
+	var id uuid.UUID
+	if err := tx.QueryRow(`INSERT INTO study_group_post (thread_id, parent_id, body, created_by) VALUES ($1, $2, $3, $4) RETURNING id`,
+		p.ThreadID, p.ParentID, p.Body, p.CreatedBy).Scan(&id); err != nil {
+		return uuid.Nil, err
+	}
+	if _, err := tx.Exec(`UPDATE study_group_thread SET post_count = post_count + 1, last_post_at = NOW() WHERE id = $1`, p.ThreadID); err != nil {
+		return uuid.Nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+// GetPosts returns a page of a thread's posts in chronological order; replies reference their parent via ParentID
+func (q *DiscussionQueries) GetPosts(threadID uuid.UUID, limit, offset int) ([]models.StudyGroupPost, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	query := `
+	SELECT p.id, p.thread_id, p.parent_id, p.body, p.created_by, u.username, p.created_at
+	FROM study_group_post p
+	JOIN users u ON u.uid = p.created_by
+	WHERE p.thread_id = $1
+	ORDER BY p.created_at ASC
+	LIMIT $2 OFFSET $3
+	`
+	rows, err := q.DB.Query(query, threadID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.StudyGroupPost{}
+	for rows.Next() {
+		var p models.StudyGroupPost
+		var parentID uuid.NullUUID
+		if err := rows.Scan(&p.ID, &p.ThreadID, &parentID, &p.Body, &p.CreatedBy, &p.AuthorUsername, &p.CreatedAt); err != nil {
+			return nil, err
+		}
+		if parentID.Valid {
+			p.ParentID = &parentID.UUID
+		}
+		res = append(res, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
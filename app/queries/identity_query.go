@@ -0,0 +1,193 @@
+package queries
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type IdentityQueries struct {
+	DB *sql.DB
+}
+
+var (
+	ErrInvalidLoginState       = errors.New("invalid or expired login state")
+	ErrIdentityLinkedElsewhere = errors.New("this account is already linked to another user")
+	ErrProviderAlreadyLinked   = errors.New("a different account of this provider is already linked")
+	ErrIdentityNotFound        = errors.New("identity not found")
+	ErrLastLoginMethod         = errors.New("cannot unlink the only way to sign in; set a password first")
+)
+
+// CreateLoginState stores a pending authorization request and drops the expired ones
+func (q *IdentityQueries) CreateLoginState(stateHash string, s *models.OIDCLoginState, expiresAt time.Time) error {
+	if _, err := q.DB.Exec(`DELETE FROM oidc_login_states WHERE expires_at < NOW()`); err != nil {
+		return err
+	}
+	_, err := q.DB.Exec(`INSERT INTO oidc_login_states (state_hash, provider, code_verifier, nonce, link_user_id, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`, stateHash, s.Provider, s.CodeVerifier, s.Nonce, s.LinkUserID, expiresAt)
+	return err
+}
+
+// ConsumeLoginState deletes the state and returns it, so every authorization response can be used only once
+func (q *IdentityQueries) ConsumeLoginState(stateHash string) (*models.OIDCLoginState, error) {
+	s := &models.OIDCLoginState{}
+	var linkUserID uuid.NullUUID
+	err := q.DB.QueryRow(`DELETE FROM oidc_login_states WHERE state_hash = $1 AND expires_at > NOW()
+		RETURNING provider, code_verifier, nonce, link_user_id`, stateHash).Scan(&s.Provider, &s.CodeVerifier, &s.Nonce, &linkUserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrInvalidLoginState
+		}
+		return nil, err
+	}
+	if linkUserID.Valid {
+		s.LinkUserID = &linkUserID.UUID
+	}
+	return s, nil
+}
+
+// GetIdentityUserEmail returns the email of the user linked to the provider account, or "" when there is none.
+// The login time and the identity's email are refreshed on the way.
+func (q *IdentityQueries) GetIdentityUserEmail(provider, subject, email string) (string, error) {
+	var userEmail string
+	err := q.DB.QueryRow(`UPDATE user_identities i SET last_login_at = NOW(), email = COALESCE(NULLIF($3, ''), i.email)
+		FROM users u
+		WHERE u.uid = i.user_id AND i.provider = $1 AND i.subject = $2
+		RETURNING u.email`, provider, subject, email).Scan(&userEmail)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+	return userEmail, nil
+}
+
+// LinkIdentity links the provider account to the user. Linking the same account again is a no-op.
+func (q *IdentityQueries) LinkIdentity(userID uuid.UUID, provider, subject, email string) error {
+	return linkIdentity(q.DB, userID, provider, subject, email)
+}
+
+// LinkIdentityAndVerify links the provider account to the user owning the provider-verified address and
+// marks that address verified. Whoever set a password on a still unverified account never proved they own
+// the address, so the password is cleared and the account signed out everywhere in the same transaction.
+// The ids of the revoked sessions are returned.
+func (q *IdentityQueries) LinkIdentityAndVerify(userID uuid.UUID, provider, subject, email string) ([]uuid.UUID, error) {
+	tx, err := q.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var verifiedAt sql.NullTime
+	if err := tx.QueryRow(`SELECT email_verified_at FROM users WHERE uid = $1 FOR UPDATE`, userID).Scan(&verifiedAt); err != nil {
+		return nil, err
+	}
+	if err := linkIdentity(tx, userID, provider, subject, email); err != nil {
+		return nil, err
+	}
+	if verifiedAt.Valid {
+		return nil, tx.Commit()
+	}
+
+	if _, err := tx.Exec(`UPDATE users SET password = '', email_verified_at = NOW(), updated_at = NOW() WHERE uid = $1`, userID); err != nil {
+		return nil, err
+	}
+	revoked, err := revokeUserSessions(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return revoked, tx.Commit()
+}
+
+func linkIdentity(db interface {
+	Exec(string, ...interface{}) (sql.Result, error)
+}, userID uuid.UUID, provider, subject, email string) error {
+	res, err := db.Exec(`INSERT INTO user_identities (user_id, provider, subject, email, last_login_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NOW())
+		ON CONFLICT (provider, subject) DO UPDATE SET last_login_at = NOW()
+		WHERE user_identities.user_id = EXCLUDED.user_id`, userID, provider, subject, email)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrProviderAlreadyLinked
+		}
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrIdentityLinkedElsewhere
+	}
+	return nil
+}
+
+func (q *IdentityQueries) GetIdentities(userID uuid.UUID) ([]models.UserIdentity, error) {
+	rows, err := q.DB.Query(`SELECT id, provider, email, created_at, last_login_at
+		FROM user_identities WHERE user_id = $1 ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.UserIdentity{}
+	for rows.Next() {
+		var i models.UserIdentity
+		var email sql.NullString
+		var lastLogin sql.NullTime
+		if err := rows.Scan(&i.ID, &i.Provider, &email, &i.CreatedAt, &lastLogin); err != nil {
+			return nil, err
+		}
+		if email.Valid {
+			i.Email = &email.String
+		}
+		if lastLogin.Valid {
+			i.LastLoginAt = &lastLogin.Time
+		}
+		res = append(res, i)
+	}
+	return res, rows.Err()
+}
+
+// UnlinkIdentity removes the user's identity for provider. It refuses with ErrLastLoginMethod when the
+// account has no password and no other linked identity, since the user could not sign in anymore.
+func (q *IdentityQueries) UnlinkIdentity(userID uuid.UUID, provider string) error {
+	tx, err := q.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var hasPassword bool
+	var others int
+	err = tx.QueryRow(`SELECT COALESCE(u.password, '') <> '',
+			(SELECT COUNT(*) FROM user_identities WHERE user_id = u.uid AND provider <> $2)
+		FROM users u WHERE u.uid = $1 FOR UPDATE`, userID, provider).Scan(&hasPassword, &others)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("user not found")
+		}
+		return err
+	}
+
+	res, err := tx.Exec(`DELETE FROM user_identities WHERE user_id = $1 AND provider = $2`, userID, provider)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrIdentityNotFound
+	}
+	if !hasPassword && others == 0 {
+		return ErrLastLoginMethod
+	}
+	return tx.Commit()
+}
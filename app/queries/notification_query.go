@@ -0,0 +1,201 @@
+package queries
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/google/uuid"
+)
+
+type NotificationQueries struct {
+	DB *sql.DB
+}
+
+const notificationSelect = `
+	SELECT n.id, n.user_id, n.actor_id, u.username, n.type, n.entity_id, n.data, n.is_read, n.created_at
+	FROM notifications n
+	LEFT JOIN users u ON u.uid = n.actor_id`
+
+func scanNotification(row interface{ Scan(...interface{}) error }) (*models.Notification, error) {
+	var n models.Notification
+	var actorID, entityID uuid.NullUUID
+	var actorUsername sql.NullString
+	var data []byte
+	if err := row.Scan(&n.ID, &n.UserID, &actorID, &actorUsername, &n.Type, &entityID, &data, &n.IsRead, &n.CreatedAt); err != nil {
+		return nil, err
+	}
+	if actorID.Valid {
+		n.ActorID = &actorID.UUID
+	}
+	if actorUsername.Valid {
+		n.ActorUsername = &actorUsername.String
+	}
+	if entityID.Valid {
+		n.EntityID = &entityID.UUID
+	}
+	n.Data = json.RawMessage(data)
+	return &n, nil
+}
+
+// CreateNotification stores a notification for userID unless they disabled that type or a block exists between them and the actor.
+// It returns uuid.Nil when the notification was suppressed by preferences.
+func (q *NotificationQueries) CreateNotification(userID, actorID uuid.UUID, typ string, entityID *uuid.UUID, data map[string]interface{}) (uuid.UUID, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if data == nil {
+		payload = []byte("{}")
+	}
+
+	query := `
+	INSERT INTO notifications (user_id, actor_id, type, entity_id, data)
+	SELECT $1, $2, $3, $4, $5
+	WHERE NOT EXISTS (
+		SELECT 1 FROM notification_preferences p WHERE p.user_id = $1 AND p.type = $3 AND p.enabled = FALSE
+	)
+	  AND NOT ` + blockedBetween("$1::uuid", "$2::uuid") + `
+	RETURNING id`
+	var id uuid.UUID
+	if err := q.DB.QueryRow(query, userID, actorID, typ, entityID, payload).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, nil
+		}
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+// NotifyStudyGroupMembers fans a notification out to every member of the group except the actor
+func (q *NotificationQueries) NotifyStudyGroupMembers(groupID, actorID uuid.UUID, typ string, entityID *uuid.UUID, data map[string]interface{}) (int64, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return 0, err
+	}
+	if data == nil {
+		payload = []byte("{}")
+	}
+
+	query := `
+	INSERT INTO notifications (user_id, actor_id, type, entity_id, data)
+	SELECT sgm.user_id, $2, $3, $4, $5
+	FROM study_group_member sgm
+	WHERE sgm.group_id = $1
+	  AND sgm.user_id != $2
+	  AND NOT EXISTS (
+		SELECT 1 FROM notification_preferences p WHERE p.user_id = sgm.user_id AND p.type = $3 AND p.enabled = FALSE
+	  )
+	  AND NOT ` + blockedBetween("sgm.user_id", "$2::uuid")
+	res, err := q.DB.Exec(query, groupID, actorID, typ, entityID, payload)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (q *NotificationQueries) GetNotifications(userID uuid.UUID, unreadOnly bool, limit, offset int) ([]models.Notification, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	query := notificationSelect + `
+	WHERE n.user_id = $1 AND ($2 = FALSE OR n.is_read = FALSE)
+	ORDER BY n.created_at DESC
+	LIMIT $3 OFFSET $4`
+	rows, err := q.DB.Query(query, userID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.Notification{}
+	for rows.Next() {
+		n, err := scanNotification(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (q *NotificationQueries) CountUnread(userID uuid.UUID) (int, error) {
+	var cnt int
+	if err := q.DB.QueryRow(`SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND is_read = FALSE`, userID).Scan(&cnt); err != nil {
+		return 0, err
+	}
+	return cnt, nil
+}
+
+func (q *NotificationQueries) MarkRead(userID, notificationID uuid.UUID) error {
+	res, err := q.DB.Exec(`UPDATE notifications SET is_read = TRUE WHERE id = $1 AND user_id = $2`, notificationID, userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errors.New("notification not found")
+	}
+	return nil
+}
+
+func (q *NotificationQueries) MarkAllRead(userID uuid.UUID) (int64, error) {
+	res, err := q.DB.Exec(`UPDATE notifications SET is_read = TRUE WHERE user_id = $1 AND is_read = FALSE`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// GetPreferences returns one entry per notification type; types without a stored row default to enabled
+func (q *NotificationQueries) GetPreferences(userID uuid.UUID) ([]models.NotificationPreference, error) {
+	rows, err := q.DB.Query(`SELECT type, enabled FROM notification_preferences WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stored := map[string]bool{}
+	for rows.Next() {
+		var typ string
+		var enabled bool
+		if err := rows.Scan(&typ, &enabled); err != nil {
+			return nil, err
+		}
+		stored[typ] = enabled
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	res := make([]models.NotificationPreference, 0, len(utils.ValidNotificationTypes))
+	for _, typ := range utils.ValidNotificationTypes {
+		enabled, ok := stored[typ]
+		if !ok {
+			enabled = true
+		}
+		res = append(res, models.NotificationPreference{Type: typ, Enabled: enabled})
+	}
+	return res, nil
+}
+
+func (q *NotificationQueries) SetPreference(userID uuid.UUID, typ string, enabled bool) error {
+	query := `
+	INSERT INTO notification_preferences (user_id, type, enabled, updated_at)
+	VALUES ($1, $2, $3, NOW())
+	ON CONFLICT (user_id, type) DO UPDATE SET enabled = EXCLUDED.enabled, updated_at = NOW()`
+	_, err := q.DB.Exec(query, userID, typ, enabled)
+	return err
+}
@@ -0,0 +1,114 @@
+package queries
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrForkSourceNotFound = errors.New("quiz not found")
+	ErrForkingDisabled    = errors.New("the author does not allow forking this quiz")
+)
+
+// ForkQuiz deep-copies a quiz with its questions and options into userID's account and records the
+// original in forked_from. Only public quizzes (or the caller's own) can be forked, and only while
+// the author allows it. The fork starts out public and outside any study group.
+func (q *QuizQueries) ForkQuiz(sourceID, userID uuid.UUID, title string) (uuid.UUID, error) {
+	tx, err := q.DB.Begin()
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback()
+
+	var ownerID uuid.UUID
+	var allowForking bool
+	err = tx.QueryRow(`SELECT created_by, allow_forking FROM quizzes
+		WHERE id = $1 AND is_hidden = FALSE AND (visibility = 'public' OR created_by = $2)`, sourceID, userID).Scan(&ownerID, &allowForking)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, ErrForkSourceNotFound
+		}
+		return uuid.Nil, err
+	}
+	if !allowForking && ownerID != userID {
+		return uuid.Nil, ErrForkingDisabled
+	}
+
+	var forkID uuid.UUID
+	err = tx.QueryRow(`INSERT INTO quizzes (title, description, difficulty_level, time_limit, created_by, subject, tags, forked_from)
+		SELECT COALESCE(NULLIF($3, ''), title), description, difficulty_level, time_limit, $2, subject, tags, id
+		FROM quizzes WHERE id = $1
+		RETURNING id`, sourceID, userID, title).Scan(&forkID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	// New question ids are derived from the fork and the original question so options can be
+	// attached without reading the questions back.
+	if _, err := tx.Exec(`INSERT INTO quiz_questions (id, quiz_id, question_text, explanation)
+		SELECT uuid_generate_v5($2, qq.id::text), $2, qq.question_text, qq.explanation
+		FROM quiz_questions qq
+		WHERE qq.quiz_id = $1
+		ORDER BY qq.created_at, qq.id`, sourceID, forkID); err != nil {
+		return uuid.Nil, err
+	}
+	if _, err := tx.Exec(`INSERT INTO quiz_options (question_id, content, is_correct)
+		SELECT uuid_generate_v5($2, qo.question_id::text), qo.content, qo.is_correct
+		FROM quiz_options qo
+		JOIN quiz_questions qq ON qq.id = qo.question_id
+		WHERE qq.quiz_id = $1
+		ORDER BY qo.created_at, qo.id`, sourceID, forkID); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return uuid.Nil, err
+	}
+	return forkID, nil
+}
+
+// SetAllowForking lets the author turn forking of their quiz on or off. Existing forks are kept.
+func (q *QuizQueries) SetAllowForking(quizID, ownerID uuid.UUID, allow bool) error {
+	res, err := q.DB.Exec(`UPDATE quizzes SET allow_forking = $1 WHERE id = $2 AND created_by = $3`, allow, quizID, ownerID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errors.New("not found or not owner")
+	}
+	return nil
+}
+
+// GetQuizForks lists the public forks of a quiz, newest first
+func (q *QuizQueries) GetQuizForks(quizID uuid.UUID, limit, offset int) ([]models.QuizSummary, error) {
+	rows, err := q.DB.Query(`SELECT q.id, q.title, q.difficulty_level, q.created_by,
+			(SELECT COUNT(*) FROM quiz_questions qq WHERE qq.quiz_id = q.id) AS total_questions
+		FROM quizzes q
+		WHERE q.forked_from = $1 AND q.is_hidden = FALSE AND q.visibility = 'public'
+		ORDER BY q.created_at DESC, q.id
+		LIMIT $2 OFFSET $3`, quizID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.QuizSummary{}
+	for rows.Next() {
+		var s models.QuizSummary
+		if err := rows.Scan(&s.ID, &s.Title, &s.Difficulty, &s.CreatedBy, &s.TotalQuestions); err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
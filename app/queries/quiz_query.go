@@ -9,7 +9,9 @@ import (
 	"time"
 
 	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type QuizQueries struct {
@@ -23,7 +25,7 @@ func (q *QuizQueries) InsertQuiz(quiz models.Quiz, createdBy string, description
 	}
 
 	var quizID string
-	query := `INSERT INTO quizzes (title, description, difficulty_level, time_limit, created_by) VALUES ($1,$2,$3,$4,$5) RETURNING id`
+	query := `INSERT INTO quizzes (title, description, difficulty_level, time_limit, created_by, subject, tags) VALUES ($1,$2,$3,$4,$5,$6,$7) RETURNING id`
 
 	var tl interface{}
 	if timeLimit != nil {
@@ -32,7 +34,12 @@ func (q *QuizQueries) InsertQuiz(quiz models.Quiz, createdBy string, description
 		tl = nil
 	}
 
-	if err := q.DB.QueryRow(query, quiz.Title, description, quiz.Difficulty, tl, userID).Scan(&quizID); err != nil {
+	subject := quiz.Subject
+	if !utils.IsValidSubject(subject) {
+		subject = utils.SubjectOther
+	}
+
+	if err := q.DB.QueryRow(query, quiz.Title, description, quiz.Difficulty, tl, userID, subject, pq.Array(utils.NormalizeTags(quiz.Tags))).Scan(&quizID); err != nil {
 		return "", err
 	}
 	return quizID, nil
@@ -180,7 +187,7 @@ func (q *QuizQueries) GetQuizzesFromFollowing(userID string) ([]models.Quiz, err
 		q.created_at
 	FROM quizzes q
 	JOIN socials s ON s.following = q.created_by
-	WHERE s.follower_id = $1
+	WHERE s.follower_id = $1 AND q.is_hidden = FALSE AND q.visibility IN ('public', 'followers')
 	ORDER BY q.created_at DESC
 	LIMIT 10
 	`
@@ -357,6 +364,9 @@ func (q *QuizQueries) GetAttemptDetail(attemptID string) (*models.AttemptDetail,
 func (q *QuizQueries) GetQuizByID(quizID string) (*models.Quiz, error) {
 	var quiz models.Quiz
 	var questionsJSON []byte
+	var shareToken sql.NullString
+	var forkedFrom uuid.NullUUID
+	var origin models.QuizOrigin
 
 	id, err := uuid.Parse(quizID)
 	if err != nil {
@@ -372,6 +382,17 @@ func (q *QuizQueries) GetQuizByID(quizID string) (*models.Quiz, error) {
             q.time_limit,
             q.created_by,
             q.created_at,
+            q.is_hidden,
+            q.subject,
+            q.tags,
+            q.visibility,
+            q.share_token,
+            q.allow_forking,
+            (SELECT COUNT(*) FROM quizzes fq WHERE fq.forked_from = q.id) AS fork_count,
+            q.forked_from,
+            COALESCE(oq.title, ''),
+            COALESCE(oq.created_by::text, ''),
+            COALESCE(ou.username, ''),
             COALESCE(json_agg(
                 json_build_object(
                     'id', qq.id,
@@ -396,8 +417,10 @@ func (q *QuizQueries) GetQuizByID(quizID string) (*models.Quiz, error) {
             ) FILTER (WHERE qq.id IS NOT NULL), '[]') AS questions
         FROM quizzes q
         LEFT JOIN quiz_questions qq ON qq.quiz_id = q.id
+        LEFT JOIN quizzes oq ON oq.id = q.forked_from AND oq.is_hidden = FALSE AND oq.visibility = 'public'
+        LEFT JOIN users ou ON ou.uid = oq.created_by
         WHERE q.id = $1
-        GROUP BY q.id, q.title, q.description, q.difficulty_level, q.time_limit, q.created_by, q.created_at;
+        GROUP BY q.id, oq.title, oq.created_by, ou.username;
     `
 
 	err = q.DB.QueryRow(query, id).Scan(
@@ -408,6 +431,17 @@ func (q *QuizQueries) GetQuizByID(quizID string) (*models.Quiz, error) {
 		&quiz.TimeLimit,
 		&quiz.CreatedBy,
 		&quiz.CreatedAt,
+		&quiz.IsHidden,
+		&quiz.Subject,
+		pq.Array(&quiz.Tags),
+		&quiz.Visibility,
+		&shareToken,
+		&quiz.AllowForking,
+		&quiz.ForkCount,
+		&forkedFrom,
+		&origin.Title,
+		&origin.AuthorID,
+		&origin.AuthorUsername,
 		&questionsJSON,
 	)
 	if err != nil {
@@ -420,17 +454,34 @@ func (q *QuizQueries) GetQuizByID(quizID string) (*models.Quiz, error) {
 	if err := json.Unmarshal(questionsJSON, &quiz.Questions); err != nil {
 		return nil, err
 	}
+	if shareToken.Valid {
+		quiz.ShareToken = &shareToken.String
+	}
+	if forkedFrom.Valid {
+		origin.QuizID = forkedFrom.UUID
+		quiz.ForkedFrom = &origin
+	}
 
 	return &quiz, nil
 }
 
-func (q *QuizQueries) GetUserLeaderboard(limit int) ([]models.UserLeaderboardEntry, error) {
-	base := `SELECT u.uid, u.username, u.image_url, COALESCE(SUM(a.score),0) as total_score FROM users u LEFT JOIN attempts_quiz a ON a.user_id = u.uid GROUP BY u.uid, u.username, u.image_url ORDER BY total_score DESC`
+// quizTagFilter matches attempts whose quiz has subject or tag $1; an empty $1 matches everything
+const quizTagFilter = `($1::text = '' OR EXISTS (SELECT 1 FROM quizzes tq WHERE tq.id = a.quiz_id AND (tq.subject = $1 OR $1 = ANY(tq.tags))))`
+
+// GetUserLeaderboard ranks users by total score. With a tag only attempts on matching quizzes count
+// and users without any are left out, e.g. the top biology learners.
+func (q *QuizQueries) GetUserLeaderboard(limit int, tag string) ([]models.UserLeaderboardEntry, error) {
+	base := `SELECT u.uid, u.username, u.image_url, COALESCE(SUM(a.score),0) as total_score
+		FROM users u
+		LEFT JOIN attempts_quiz a ON a.user_id = u.uid AND ` + quizTagFilter + `
+		GROUP BY u.uid, u.username, u.image_url
+		HAVING $1 = '' OR COUNT(a.id) > 0
+		ORDER BY total_score DESC`
 	if limit > 0 {
 		base += ` LIMIT ` + fmt.Sprintf("%d", limit)
 	}
 
-	rows, err := q.DB.Query(base)
+	rows, err := q.DB.Query(base, tag)
 	if err != nil {
 		return nil, err
 	}
@@ -450,20 +501,21 @@ func (q *QuizQueries) GetUserLeaderboard(limit int) ([]models.UserLeaderboardEnt
 	return res, nil
 }
 
-func (q *QuizQueries) GetStudyGroupLeaderboard(limit int) ([]models.StudyGroupLeaderboardEntry, error) {
+func (q *QuizQueries) GetStudyGroupLeaderboard(limit int, tag string) ([]models.StudyGroupLeaderboardEntry, error) {
 	query := `
 SELECT sg.id, sg.name, sg.member_count, COALESCE(SUM(a.score),0) AS total_score
 FROM study_group sg
 LEFT JOIN study_group_member sgm ON sgm.group_id = sg.id
-LEFT JOIN attempts_quiz a ON a.user_id = sgm.user_id
+LEFT JOIN attempts_quiz a ON a.user_id = sgm.user_id AND ` + quizTagFilter + `
 GROUP BY sg.id, sg.name, sg.member_count
+HAVING $1 = '' OR COUNT(a.id) > 0
 ORDER BY total_score DESC
 `
 	if limit > 0 {
 		query += ` LIMIT ` + fmt.Sprintf("%d", limit)
 	}
 
-	rows, err := q.DB.Query(query)
+	rows, err := q.DB.Query(query, tag)
 	if err != nil {
 		return nil, err
 	}
@@ -492,60 +544,152 @@ func (q *QuizQueries) HasUserAttemptedQuiz(quizID string, userID string) (bool,
 	return cnt > 0, nil
 }
 
-func (q *QuizQueries) GetFeedWithLikes(userID string) ([]map[string]interface{}, error) {
+// feedItemsCTE collects every activity visible to $1 (the viewer). When the viewer follows nobody,
+// popular quizzes from other users stand in for the follow-based activity. Activity involving anyone
+// in a block relationship with the viewer is left out, as are quizzes the viewer is not allowed to see.
+// A non-empty $3 keeps only quizzes with that subject or tag.
+var feedItemsCTE = `
+WITH items AS (
+	SELECT 'new_quiz' AS kind, q.id AS item_id, q.id AS quiz_id, q.created_by AS actor_id,
+		NULL::int AS score, NULL::int AS total_questions, NULL::uuid AS group_id, q.created_at AS occurred_at
+	FROM quizzes q
+	JOIN socials s ON s.following = q.created_by
+	WHERE s.follower_id = $1
+	UNION ALL
+	SELECT 'friend_high_score', a.id, a.quiz_id, a.user_id,
+		a.score, a.total_questions, NULL::uuid, a.submitted_at
+	FROM attempts_quiz a
+	JOIN socials s ON s.following = a.user_id
+	WHERE s.follower_id = $1 AND a.score * 100 >= a.total_questions * 80
+	UNION ALL
+	SELECT 'group_assignment', q.id, q.id, q.created_by,
+		NULL::int, NULL::int, q.study_group_id, COALESCE(q.assigned_at, q.created_at)
+	FROM quizzes q
+	JOIN study_group_member m ON m.group_id = q.study_group_id
+	WHERE m.user_id = $1 AND q.created_by != $1
+	UNION ALL
+	SELECT 'popular_quiz', q.id, q.id, q.created_by,
+		NULL::int, NULL::int, NULL::uuid, q.created_at
+	FROM quizzes q
+	WHERE q.created_by != $1
+	  AND NOT EXISTS (SELECT 1 FROM socials s WHERE s.follower_id = $1)
+), scored AS (
+	SELECT i.*, q.title, COALESCE(q.description, '') AS description, q.difficulty_level, q.created_by AS quiz_owner, q.created_at AS quiz_created_at,
+		COALESCE((SELECT COUNT(*) FROM attempts_quiz a WHERE a.quiz_id = q.id), 0) AS attempts_count,
+		COALESCE((SELECT COUNT(*) FROM likes l WHERE l.quiz_id = q.id), 0) AS likes_count,
+		EXISTS(SELECT 1 FROM likes l2 WHERE l2.quiz_id = q.id AND l2.liked_by = $1) AS is_likedbyme
+	FROM items i
+	JOIN quizzes q ON q.id = i.quiz_id AND q.is_hidden = FALSE
+		AND ($3::text = '' OR q.subject = $3 OR $3 = ANY(q.tags))
+		AND ` + quizVisibleTo("q", "$1") + `
+)
+SELECT s.kind, s.item_id, s.occurred_at, s.quiz_id, s.title, s.description, s.difficulty_level, s.quiz_owner,
+	s.attempts_count, s.likes_count, s.is_likedbyme, s.quiz_created_at,
+	s.actor_id, u.username, s.score, s.total_questions, s.group_id, sg.name,
+	(1 + 2 * s.likes_count + s.attempts_count)::float8
+		/ power(GREATEST(EXTRACT(EPOCH FROM ($2::timestamp - s.occurred_at)) / 3600.0, 0) + 2, 1.5) AS rank
+FROM scored s
+JOIN users u ON u.uid = s.actor_id
+LEFT JOIN study_group sg ON sg.id = s.group_id
+WHERE NOT EXISTS (
+	SELECT 1 FROM user_blocks ub
+	WHERE (ub.blocker_id = $1 AND ub.blocked_id IN (s.actor_id, s.quiz_owner))
+	   OR (ub.blocked_id = $1 AND ub.blocker_id IN (s.actor_id, s.quiz_owner))
+)
+`
+
+// GetFeedWithLikes returns one page of the viewer's activity feed. In latest mode items are ordered by
+// when they happened; in ranked mode by a score that weighs likes and attempts against age.
+// A non-empty tag narrows the feed to quizzes with that subject or tag.
+func (q *QuizQueries) GetFeedWithLikes(userID string, mode string, tag string, cursor *models.FeedCursor, limit int) (*models.FeedPage, error) {
 	uid, err := uuid.Parse(userID)
 	if err != nil {
 		return nil, err
 	}
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+	if mode != models.FeedModeRanked {
+		mode = models.FeedModeLatest
+	}
 
-	query := `
-SELECT q.id, q.title, q.description, q.difficulty_level, q.created_by,
-	COALESCE((SELECT COUNT(*) FROM attempts_quiz a WHERE a.quiz_id = q.id), 0) AS attempts_count,
-	COALESCE((SELECT COUNT(*) FROM likes l WHERE l.quiz_id = q.id), 0) AS likes_count,
-	EXISTS(SELECT 1 FROM likes l2 WHERE l2.quiz_id = q.id AND l2.liked_by = $1) AS is_likedbyme,
-	q.created_at
-FROM quizzes q
-JOIN socials s ON s.following = q.created_by
-WHERE s.follower_id = $1
-ORDER BY q.created_at DESC
-LIMIT 10
-`
+	now := time.Now().UTC()
+	if cursor != nil {
+		if cursor.Mode != mode || cursor.Tag != tag {
+			return nil, fmt.Errorf("cursor does not match feed mode")
+		}
+		now = cursor.Now
+	}
 
-	rows, err := q.DB.Query(query, uid)
+	args := []interface{}{uid, now, tag}
+	query := `SELECT * FROM (` + feedItemsCTE + `) f`
+	if mode == models.FeedModeRanked {
+		if cursor != nil {
+			query += ` WHERE (f.rank, f.kind, f.item_id) < ($4, $5, $6)`
+			args = append(args, cursor.Rank, cursor.Kind, cursor.ItemID)
+		}
+		query += ` ORDER BY f.rank DESC, f.kind DESC, f.item_id DESC`
+	} else {
+		if cursor != nil {
+			query += ` WHERE (f.occurred_at, f.kind, f.item_id) < ($4, $5, $6)`
+			args = append(args, cursor.OccurredAt, cursor.Kind, cursor.ItemID)
+		}
+		query += ` ORDER BY f.occurred_at DESC, f.kind DESC, f.item_id DESC`
+	}
+	query += fmt.Sprintf(` LIMIT %d`, limit+1)
+
+	rows, err := q.DB.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	res := make([]map[string]interface{}, 0)
+	items := []models.FeedItem{}
 	for rows.Next() {
-		var id uuid.UUID
-		var title, description, difficulty string
-		var createdBy uuid.UUID
-		var attemptsCount, likesCount int
-		var isLiked bool
-		var createdAt time.Time
-
-		if err := rows.Scan(&id, &title, &description, &difficulty, &createdBy, &attemptsCount, &likesCount, &isLiked, &createdAt); err != nil {
+		var it models.FeedItem
+		var score, totalQuestions sql.NullInt64
+		var groupID uuid.NullUUID
+		var groupName sql.NullString
+		if err := rows.Scan(&it.Kind, &it.ItemID, &it.OccurredAt, &it.Quiz.ID, &it.Quiz.Title, &it.Quiz.Description, &it.Quiz.Difficulty, &it.Quiz.CreatedBy,
+			&it.Quiz.Attempts, &it.Quiz.LikesCount, &it.Quiz.IsLikedByMe, &it.Quiz.CreatedAt,
+			&it.Actor.ID, &it.Actor.Username, &score, &totalQuestions, &groupID, &groupName, &it.Rank); err != nil {
 			return nil, err
 		}
-
-		res = append(res, map[string]interface{}{
-			"id":           id.String(),
-			"title":        title,
-			"description":  description,
-			"difficulty":   difficulty,
-			"created_by":   createdBy.String(),
-			"attempts":     attemptsCount,
-			"likes_count":  likesCount,
-			"is_likedbyme": isLiked,
-			"created_at":   createdAt,
-		})
+		if score.Valid {
+			v := int(score.Int64)
+			it.Score = &v
+		}
+		if totalQuestions.Valid {
+			v := int(totalQuestions.Int64)
+			it.TotalQuestions = &v
+		}
+		if groupID.Valid {
+			it.StudyGroup = &models.FeedGroup{ID: groupID.UUID, Name: groupName.String}
+		}
+		if mode != models.FeedModeRanked {
+			it.Rank = 0
+		}
+		items = append(items, it)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
-	return res, nil
+
+	page := &models.FeedPage{Items: items}
+	if len(items) > limit {
+		page.Items = items[:limit]
+		last := page.Items[limit-1]
+		next := models.FeedCursor{Mode: mode, Tag: tag, Kind: last.Kind, ItemID: last.ItemID, Now: now}
+		if mode == models.FeedModeRanked {
+			next.Rank = last.Rank
+		} else {
+			next.OccurredAt = last.OccurredAt
+		}
+		if page.NextCursor, err = utils.EncodeCursor(next); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
 }
 
 func (q *QuizQueries) AssignQuizToStudyGroup(quizID string, studyGroupID string) error {
@@ -558,7 +702,7 @@ func (q *QuizQueries) AssignQuizToStudyGroup(quizID string, studyGroupID string)
 		return err
 	}
 
-	res, err := q.DB.Exec(`UPDATE quizzes SET study_group_id = $1 WHERE id = $2`, gUUID, qUUID)
+	res, err := q.DB.Exec(`UPDATE quizzes SET study_group_id = $1, assigned_at = NOW() WHERE id = $2`, gUUID, qUUID)
 	if err != nil {
 		return err
 	}
@@ -572,7 +716,8 @@ func (q *QuizQueries) AssignQuizToStudyGroup(quizID string, studyGroupID string)
 	return nil
 }
 
-func (q *QuizQueries) GetQuizzesByStudyGroup(studyGroupID string, limit int) ([]models.Quiz, error) {
+// GetQuizzesByStudyGroup lists the group's quizzes that viewer (uuid.Nil for anonymous) is allowed to see
+func (q *QuizQueries) GetQuizzesByStudyGroup(studyGroupID string, viewer uuid.UUID, limit int) ([]models.Quiz, error) {
 	gUUID, err := uuid.Parse(studyGroupID)
 	if err != nil {
 		return nil, err
@@ -583,13 +728,14 @@ func (q *QuizQueries) GetQuizzesByStudyGroup(studyGroupID string, limit int) ([]
 			COALESCE((SELECT COUNT(*) FROM quiz_questions qq WHERE qq.quiz_id = q.id), 0) as total_questions
 		FROM quizzes q
 		JOIN users u ON u.uid = q.created_by
-		WHERE q.study_group_id = $1
+		WHERE q.study_group_id = $1 AND q.is_hidden = FALSE
+		  AND ` + quizVisibleTo("q", "$2::uuid") + `
 		ORDER BY q.created_at DESC`
 	if limit > 0 {
 		base += ` LIMIT ` + fmt.Sprintf("%d", limit)
 	}
 
-	rows, err := q.DB.Query(base, gUUID)
+	rows, err := q.DB.Query(base, gUUID, viewer)
 	if err != nil {
 		return nil, err
 	}
@@ -610,3 +756,28 @@ func (q *QuizQueries) GetQuizzesByStudyGroup(studyGroupID string, limit int) ([]
 	}
 	return res, nil
 }
+
+// GetQuizTitleInStudyGroup returns the quiz title when the quiz is assigned to the study group, or an empty string otherwise
+func (q *QuizQueries) GetQuizTitleInStudyGroup(quizID, studyGroupID uuid.UUID) (string, error) {
+	var title string
+	err := q.DB.QueryRow(`SELECT title FROM quizzes WHERE id = $1 AND study_group_id = $2`, quizID, studyGroupID).Scan(&title)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+	return title, nil
+}
+
+func (q *QuizQueries) GetQuizOwnerID(quizID string) (uuid.UUID, error) {
+	id, err := uuid.Parse(quizID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	var owner uuid.UUID
+	if err := q.DB.QueryRow(`SELECT created_by FROM quizzes WHERE id = $1`, id).Scan(&owner); err != nil {
+		return uuid.Nil, err
+	}
+	return owner, nil
+}
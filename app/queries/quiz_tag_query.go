@@ -0,0 +1,116 @@
+package queries
+
+import (
+	"errors"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// UpdateQuizTags replaces the subject and tags of a quiz owned by ownerID
+func (q *QuizQueries) UpdateQuizTags(quizID, ownerID uuid.UUID, subject string, tags []string) error {
+	res, err := q.DB.Exec(`UPDATE quizzes SET subject = $1, tags = $2 WHERE id = $3 AND created_by = $4`, subject, pq.Array(tags), quizID, ownerID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errors.New("not found or not owner")
+	}
+	return nil
+}
+
+// GetQuizzesByTag lists visible quizzes filed under the subject (bySubject) or carrying the tag, newest first
+func (q *QuizQueries) GetQuizzesByTag(tag string, bySubject bool, limit, offset int) ([]models.Quiz, error) {
+	if limit <= 0 || limit > 50 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	match := `$1 = ANY(q.tags)`
+	if bySubject {
+		match = `q.subject = $1`
+	}
+	query := `
+	SELECT q.id, q.title, COALESCE(q.description, ''), q.difficulty_level, q.created_by, q.created_at, q.subject, q.tags,
+		COALESCE((SELECT COUNT(*) FROM quiz_questions qq WHERE qq.quiz_id = q.id), 0) AS total_questions,
+		COALESCE((SELECT COUNT(*) FROM attempts_quiz a WHERE a.quiz_id = q.id), 0) AS attempts
+	FROM quizzes q
+	WHERE ` + match + ` AND q.is_hidden = FALSE AND q.visibility = 'public'
+	ORDER BY q.created_at DESC, q.id
+	LIMIT $2 OFFSET $3`
+	rows, err := q.DB.Query(query, tag, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.Quiz{}
+	for rows.Next() {
+		var quiz models.Quiz
+		var totalQuestions int
+		if err := rows.Scan(&quiz.ID, &quiz.Title, &quiz.Description, &quiz.Difficulty, &quiz.CreatedBy, &quiz.CreatedAt,
+			&quiz.Subject, pq.Array(&quiz.Tags), &totalQuestions, &quiz.Attempts); err != nil {
+			return nil, err
+		}
+		quiz.TotalQuestions = &totalQuestions
+		res = append(res, quiz)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetPopularTags returns the most used quiz tags
+func (q *QuizQueries) GetPopularTags(limit int) ([]models.TagCount, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 30
+	}
+	query := `
+	SELECT t.tag, COUNT(*) AS quiz_count
+	FROM quizzes q, unnest(q.tags) AS t(tag)
+	WHERE q.is_hidden = FALSE AND q.visibility = 'public'
+	GROUP BY t.tag
+	ORDER BY quiz_count DESC, t.tag
+	LIMIT $1`
+	return q.scanTagCounts(query, limit)
+}
+
+// GetSubjectCounts returns how many visible quizzes each subject has
+func (q *QuizQueries) GetSubjectCounts() ([]models.TagCount, error) {
+	query := `
+	SELECT q.subject, COUNT(*) AS quiz_count
+	FROM quizzes q
+	WHERE q.is_hidden = FALSE AND q.visibility = 'public'
+	GROUP BY q.subject
+	ORDER BY quiz_count DESC, q.subject`
+	return q.scanTagCounts(query)
+}
+
+func (q *QuizQueries) scanTagCounts(query string, args ...interface{}) ([]models.TagCount, error) {
+	rows, err := q.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.TagCount{}
+	for rows.Next() {
+		var t models.TagCount
+		if err := rows.Scan(&t.Name, &t.QuizCount); err != nil {
+			return nil, err
+		}
+		res = append(res, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,107 @@
+package queries
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var ErrQuizNotInStudyGroup = errors.New("quiz must be assigned to a study group to use group visibility")
+
+// quizVisibleTo is a SQL condition that holds when the viewer may see quiz alias in listings.
+// Unlisted quizzes are only listed for their owner; everyone else needs the share token.
+func quizVisibleTo(alias, viewer string) string {
+	return fmt.Sprintf(`(%[1]s.created_by = %[2]s
+		OR %[1]s.visibility = 'public'
+		OR (%[1]s.visibility = 'followers' AND EXISTS (SELECT 1 FROM socials vs WHERE vs.follower_id = %[2]s AND vs.following = %[1]s.created_by))
+		OR (%[1]s.visibility = 'group' AND EXISTS (SELECT 1 FROM study_group_member vm WHERE vm.group_id = %[1]s.study_group_id AND vm.user_id = %[2]s)))`, alias, viewer)
+}
+
+// CanViewQuiz reports whether viewer (uuid.Nil for anonymous) may open the quiz, either through its
+// visibility or by presenting the share token. Hidden quizzes are never viewable.
+func (q *QuizQueries) CanViewQuiz(quizID, viewer uuid.UUID, shareToken string) (bool, error) {
+	query := `SELECT EXISTS(
+		SELECT 1 FROM quizzes q
+		WHERE q.id = $1 AND q.is_hidden = FALSE
+		  AND (` + quizVisibleTo("q", "$2::uuid") + `
+			OR ($3 <> '' AND q.share_token = $3))
+	)`
+	var ok bool
+	if err := q.DB.QueryRow(query, quizID, viewer, shareToken).Scan(&ok); err != nil {
+		return false, err
+	}
+	return ok, nil
+}
+
+// SetQuizVisibility changes the visibility of the owner's quiz. Unlisted quizzes get a share token
+// when they do not have one yet; the current token is returned.
+func (q *QuizQueries) SetQuizVisibility(quizID, ownerID uuid.UUID, visibility string) (*string, error) {
+	tx, err := q.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var groupID uuid.NullUUID
+	var token sql.NullString
+	err = tx.QueryRow(`SELECT study_group_id, share_token FROM quizzes WHERE id = $1 AND created_by = $2 FOR UPDATE`, quizID, ownerID).Scan(&groupID, &token)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("not found or not owner")
+		}
+		return nil, err
+	}
+	if visibility == utils.VisibilityGroup && !groupID.Valid {
+		return nil, ErrQuizNotInStudyGroup
+	}
+	if visibility == utils.VisibilityUnlisted && !token.Valid {
+		t, err := utils.GenerateInviteCode(24)
+		if err != nil {
+			return nil, err
+		}
+		token = sql.NullString{String: t, Valid: true}
+	}
+
+	if _, err := tx.Exec(`UPDATE quizzes SET visibility = $1, share_token = $2 WHERE id = $3`, visibility, token, quizID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	if token.Valid {
+		return &token.String, nil
+	}
+	return nil, nil
+}
+
+// RotateQuizShareToken replaces the share token so old links stop working
+func (q *QuizQueries) RotateQuizShareToken(quizID, ownerID uuid.UUID) (string, error) {
+	const maxAttempt = 3
+
+	for i := 0; i < maxAttempt; i++ {
+		token, err := utils.GenerateInviteCode(24)
+		if err != nil {
+			return "", err
+		}
+		res, err := q.DB.Exec(`UPDATE quizzes SET share_token = $1 WHERE id = $2 AND created_by = $3`, token, quizID, ownerID)
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+				continue
+			}
+			return "", err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return "", err
+		}
+		if n == 0 {
+			return "", errors.New("not found or not owner")
+		}
+		return token, nil
+	}
+	return "", errors.New("failed to generate unique share token")
+}
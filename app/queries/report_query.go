@@ -0,0 +1,218 @@
+package queries
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrReportAlreadyOpen = errors.New("you already reported this")
+	ErrReportNotOpen     = errors.New("report is already resolved")
+	ErrReportTarget      = errors.New("reported content not found")
+)
+
+type ReportQueries struct {
+	DB *sql.DB
+}
+
+// reportTargetTables maps a report target type to its table, primary key and author column
+var reportTargetTables = map[string][3]string{
+	utils.ReportTargetQuiz:       {"quizzes", "id", "created_by"},
+	utils.ReportTargetComment:    {"comments", "id", "commenter_by"},
+	utils.ReportTargetUser:       {"users", "uid", "uid"},
+	utils.ReportTargetStudyGroup: {"study_group", "id", "created_by"},
+}
+
+// targetAuthor returns the user responsible for the reported target, or uuid.Nil when it does not exist
+func targetAuthor(db interface {
+	QueryRow(string, ...interface{}) *sql.Row
+}, targetType string, targetID uuid.UUID) (uuid.UUID, error) {
+	t, ok := reportTargetTables[targetType]
+	if !ok {
+		return uuid.Nil, fmt.Errorf("invalid target type")
+	}
+	var author uuid.UUID
+	err := db.QueryRow(fmt.Sprintf(`SELECT %s FROM %s WHERE %s = $1`, t[2], t[0], t[1]), targetID).Scan(&author)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, nil
+	}
+	return author, err
+}
+
+func (q *ReportQueries) CreateReport(r *models.Report) error {
+	author, err := targetAuthor(q.DB, r.TargetType, r.TargetID)
+	if err != nil {
+		return err
+	}
+	if author == uuid.Nil {
+		return ErrReportTarget
+	}
+
+	query := `
+	INSERT INTO reports (reporter_id, target_type, target_id, reason, details)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING id, status, created_at`
+	err = q.DB.QueryRow(query, r.ReporterID, r.TargetType, r.TargetID, r.Reason, r.Details).Scan(&r.ID, &r.Status, &r.CreatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrReportAlreadyOpen
+		}
+		return err
+	}
+	return nil
+}
+
+const reportSelect = `
+	SELECT r.id, r.reporter_id, u.username, r.target_type, r.target_id, r.reason, r.details, r.status,
+		r.resolution_action, r.resolution_note, r.resolved_by, r.resolved_at, r.created_at,
+		(SELECT COUNT(*) FROM reports o WHERE o.target_type = r.target_type AND o.target_id = r.target_id AND o.status = 'open') AS open_reports
+	FROM reports r
+	JOIN users u ON u.uid = r.reporter_id`
+
+func scanReport(row interface{ Scan(...interface{}) error }) (*models.Report, error) {
+	var r models.Report
+	var action, note sql.NullString
+	var resolvedBy uuid.NullUUID
+	var resolvedAt sql.NullTime
+	if err := row.Scan(&r.ID, &r.ReporterID, &r.ReporterUsername, &r.TargetType, &r.TargetID, &r.Reason, &r.Details, &r.Status,
+		&action, &note, &resolvedBy, &resolvedAt, &r.CreatedAt, &r.OpenReports); err != nil {
+		return nil, err
+	}
+	if action.Valid {
+		r.ResolutionAction = &action.String
+	}
+	if note.Valid {
+		r.ResolutionNote = &note.String
+	}
+	if resolvedBy.Valid {
+		r.ResolvedBy = &resolvedBy.UUID
+	}
+	if resolvedAt.Valid {
+		r.ResolvedAt = &resolvedAt.Time
+	}
+	return &r, nil
+}
+
+func (q *ReportQueries) GetReport(id uuid.UUID) (*models.Report, error) {
+	r, err := scanReport(q.DB.QueryRow(reportSelect+` WHERE r.id = $1`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetReports lists reports for the moderation queue. Open reports come out oldest first so nothing starves.
+func (q *ReportQueries) GetReports(status, targetType string, limit, offset int) ([]models.Report, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	order := "r.created_at DESC"
+	if status == utils.ReportStatusOpen {
+		order = "r.created_at ASC"
+	}
+
+	query := reportSelect + `
+	WHERE ($1 = '' OR r.status = $1) AND ($2 = '' OR r.target_type = $2)
+	ORDER BY ` + order + `
+	LIMIT $3 OFFSET $4`
+	rows, err := q.DB.Query(query, status, targetType, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.Report{}
+	for rows.Next() {
+		r, err := scanReport(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// ResolveReport applies a moderation action and closes every open report on the same target.
+// suspendUntil is only used by suspend_user; nil suspends indefinitely.
+// It returns the number of closed reports and the ids of the sessions revoked by a suspension.
+func (q *ReportQueries) ResolveReport(reportID, adminID uuid.UUID, action, note string, suspendUntil *time.Time) (int64, []uuid.UUID, error) {
+	tx, err := q.DB.Begin()
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback()
+
+	var targetType, status string
+	var targetID uuid.UUID
+	err = tx.QueryRow(`SELECT target_type, target_id, status FROM reports WHERE id = $1 FOR UPDATE`, reportID).Scan(&targetType, &targetID, &status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil, sql.ErrNoRows
+		}
+		return 0, nil, err
+	}
+	if status != utils.ReportStatusOpen {
+		return 0, nil, ErrReportNotOpen
+	}
+
+	var revoked []uuid.UUID
+	newStatus := utils.ReportStatusActioned
+	switch action {
+	case utils.ModerationDismiss:
+		newStatus = utils.ReportStatusDismissed
+	case utils.ModerationHideContent:
+		if targetType == utils.ReportTargetUser {
+			return 0, nil, fmt.Errorf("users cannot be hidden, suspend them instead")
+		}
+		t := reportTargetTables[targetType]
+		if _, err := tx.Exec(fmt.Sprintf(`UPDATE %s SET is_hidden = TRUE WHERE %s = $1`, t[0], t[1]), targetID); err != nil {
+			return 0, nil, err
+		}
+	case utils.ModerationSuspendUser:
+		author, err := targetAuthor(tx, targetType, targetID)
+		if err != nil {
+			return 0, nil, err
+		}
+		if author == uuid.Nil {
+			return 0, nil, ErrReportTarget
+		}
+		if revoked, err = suspendUser(tx, author, suspendUntil); err != nil {
+			if errors.Is(err, ErrAdminUserNotFound) {
+				return 0, nil, ErrReportTarget
+			}
+			return 0, nil, err
+		}
+	default:
+		return 0, nil, fmt.Errorf("invalid moderation action")
+	}
+
+	// dismissing only closes this report; an action settles every open report on the target
+	query := `
+	UPDATE reports SET status = $1, resolution_action = $2, resolution_note = NULLIF($3, ''), resolved_by = $4, resolved_at = NOW()
+	WHERE status = 'open' AND (id = $5 OR ($1 = 'actioned' AND target_type = $6 AND target_id = $7))`
+	res, err := tx.Exec(query, newStatus, action, note, adminID, reportID, targetType, targetID)
+	if err != nil {
+		return 0, nil, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, nil, err
+	}
+	return n, revoked, tx.Commit()
+}
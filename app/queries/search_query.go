@@ -0,0 +1,125 @@
+package queries
+
+import (
+	"database/sql"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/lib/pq"
+)
+
+type SearchQueries struct {
+	DB *sql.DB
+}
+
+// trigramThreshold is the minimum similarity for typo-tolerant matches when full-text search misses
+const trigramThreshold = 0.3
+
+func (q *SearchQueries) SearchUsers(f models.SearchFilter) ([]models.UserSearchResult, error) {
+	query := `
+	SELECT u.uid, u.username, u.image_url,
+		(SELECT COUNT(*) FROM socials s WHERE s.following = u.uid) AS follower_count,
+		CASE WHEN u.username ILIKE $1 || '%' THEN 1.0 ELSE 0 END + similarity(u.username, $1) AS rank
+	FROM users u
+	WHERE (u.username ILIKE '%' || $1 || '%' OR similarity(u.username, $1) >= $2)
+	  AND (u.suspended_at IS NULL OR u.suspended_until < NOW())
+	  AND NOT ` + blockedBetween("$3::uuid", "u.uid") + `
+	ORDER BY rank DESC, follower_count DESC, u.uid
+	LIMIT $4 OFFSET $5`
+	rows, err := q.DB.Query(query, f.Query, trigramThreshold, f.ViewerID, f.Limit, f.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.UserSearchResult{}
+	for rows.Next() {
+		var r models.UserSearchResult
+		var imageURL sql.NullString
+		if err := rows.Scan(&r.ID, &r.Username, &imageURL, &r.FollowerCount, &r.Rank); err != nil {
+			return nil, err
+		}
+		if imageURL.Valid {
+			r.ImageURL = &imageURL.String
+		}
+		res = append(res, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// SearchQuizzes matches title, description and question text with full-text search, falling back
+// to trigram similarity on the title so misspelled queries still find something
+func (q *SearchQueries) SearchQuizzes(f models.SearchFilter) ([]models.QuizSearchResult, error) {
+	query := `
+	WITH query AS (SELECT websearch_to_tsquery('simple', $1) AS tsq)
+	SELECT qz.id, qz.title, COALESCE(qz.description, ''), qz.difficulty_level, qz.created_by, u.username,
+		(SELECT COUNT(*) FROM quiz_questions qq WHERE qq.quiz_id = qz.id) AS total_questions,
+		ts_headline('simple', COALESCE(qz.description, qz.title), query.tsq, 'MaxFragments=1, MaxWords=20, MinWords=5') AS headline,
+		ts_rank(qz.search_vector, query.tsq) + 0.5 * similarity(qz.title, $1) AS rank
+	FROM quizzes qz
+	CROSS JOIN query
+	JOIN users u ON u.uid = qz.created_by
+	WHERE (qz.search_vector @@ query.tsq OR similarity(qz.title, $1) >= $2)
+	  AND qz.is_hidden = FALSE
+	  AND ($3 = '' OR qz.difficulty_level = $3)
+	  AND NOT ` + blockedBetween("$4::uuid", "qz.created_by") + `
+	  AND ` + quizVisibleTo("qz", "$4::uuid") + `
+	ORDER BY rank DESC, qz.created_at DESC, qz.id
+	LIMIT $5 OFFSET $6`
+	rows, err := q.DB.Query(query, f.Query, trigramThreshold, f.Difficulty, f.ViewerID, f.Limit, f.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.QuizSearchResult{}
+	for rows.Next() {
+		var r models.QuizSearchResult
+		if err := rows.Scan(&r.ID, &r.Title, &r.Description, &r.Difficulty, &r.CreatedBy, &r.CreatorUsername, &r.TotalQuestions, &r.Headline, &r.Rank); err != nil {
+			return nil, err
+		}
+		res = append(res, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// SearchStudyGroups only returns public groups that have not been hidden by moderators
+func (q *SearchQueries) SearchStudyGroups(f models.SearchFilter) ([]models.StudyGroupSearchResult, error) {
+	query := `
+	WITH query AS (SELECT websearch_to_tsquery('simple', $1) AS tsq)
+	SELECT sg.id, sg.name, COALESCE(sg.description, ''), sg.subject_tags, sg.member_count, sg.max_member,
+		ts_rank(setweight(to_tsvector('simple', sg.name), 'A') || setweight(to_tsvector('simple', COALESCE(sg.description, '')), 'B'), query.tsq)
+			+ 0.5 * similarity(sg.name, $1) AS rank
+	FROM study_group sg
+	CROSS JOIN query
+	WHERE ((setweight(to_tsvector('simple', sg.name), 'A') || setweight(to_tsvector('simple', COALESCE(sg.description, '')), 'B')) @@ query.tsq
+		OR similarity(sg.name, $1) >= $2
+		OR lower($1) = ANY(sg.subject_tags))
+	  AND sg.is_private = FALSE
+	  AND sg.is_hidden = FALSE
+	ORDER BY rank DESC, sg.member_count DESC, sg.id
+	LIMIT $3 OFFSET $4`
+	rows, err := q.DB.Query(query, f.Query, trigramThreshold, f.Limit, f.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.StudyGroupSearchResult{}
+	for rows.Next() {
+		var r models.StudyGroupSearchResult
+		if err := rows.Scan(&r.ID, &r.Name, &r.Description, pq.Array(&r.SubjectTags), &r.MemberCount, &r.MaxMember, &r.Rank); err != nil {
+			return nil, err
+		}
+		res = append(res, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
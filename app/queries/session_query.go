@@ -0,0 +1,194 @@
+package queries
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/google/uuid"
+)
+
+type SessionQueries struct {
+	DB *sql.DB
+}
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used")
+	ErrSessionNotFound     = errors.New("session not found")
+)
+
+func (q *SessionQueries) CreateSession(userID uuid.UUID, tokenHash, userAgent, ip string, expiresAt time.Time) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := q.DB.QueryRow(`INSERT INTO user_sessions (user_id, refresh_token_hash, user_agent, ip_address, expires_at)
+		VALUES ($1, $2, $3, $4, $5) RETURNING id`, userID, tokenHash, userAgent, ip, expiresAt).Scan(&id)
+	return id, err
+}
+
+// RotateSession swaps the session's refresh token for newHash and returns the session with the user
+// it belongs to. Presenting a token that was already rotated away means it leaked, so the whole
+// session is revoked and ErrRefreshTokenReused is returned.
+func (q *SessionQueries) RotateSession(tokenHash, newHash string, expiresAt time.Time) (uuid.UUID, *models.User, error) {
+	tx, err := q.DB.Begin()
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	defer tx.Rollback()
+
+	var sessionID uuid.UUID
+	var user models.User
+	var role sql.NullString
+	var suspendedAt, suspendedUntil sql.NullTime
+	var currentExpiry time.Time
+	var revokedAt sql.NullTime
+	err = tx.QueryRow(`SELECT s.id, s.expires_at, s.revoked_at, u.uid, u.email, u.user_role, u.suspended_at, u.suspended_until
+		FROM user_sessions s
+		JOIN users u ON u.uid = s.user_id
+		WHERE s.refresh_token_hash = $1
+		FOR UPDATE OF s`, tokenHash).Scan(&sessionID, &currentExpiry, &revokedAt, &user.ID, &user.Email, &role, &suspendedAt, &suspendedUntil)
+	if err == sql.ErrNoRows {
+		res, err := tx.Exec(`UPDATE user_sessions SET revoked_at = NOW() WHERE previous_token_hash = $1 AND revoked_at IS NULL`, tokenHash)
+		if err != nil {
+			return uuid.Nil, nil, err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			if err := tx.Commit(); err != nil {
+				return uuid.Nil, nil, err
+			}
+			return uuid.Nil, nil, ErrRefreshTokenReused
+		}
+		return uuid.Nil, nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	if revokedAt.Valid || !currentExpiry.After(time.Now()) {
+		return uuid.Nil, nil, ErrInvalidRefreshToken
+	}
+
+	if _, err := tx.Exec(`UPDATE user_sessions
+		SET previous_token_hash = refresh_token_hash, refresh_token_hash = $1, last_used_at = NOW(), expires_at = $2
+		WHERE id = $3`, newHash, expiresAt, sessionID); err != nil {
+		return uuid.Nil, nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	user.UserRole = role.String
+	if suspendedAt.Valid {
+		user.SuspendedAt = &suspendedAt.Time
+	}
+	if suspendedUntil.Valid {
+		user.SuspendedUntil = &suspendedUntil.Time
+	}
+	return sessionID, &user, nil
+}
+
+// GetActiveSessions lists the user's sessions that are neither revoked nor expired, most recently used first
+func (q *SessionQueries) GetActiveSessions(userID, currentID uuid.UUID) ([]models.Session, error) {
+	rows, err := q.DB.Query(`SELECT id, user_agent, ip_address, created_at, last_used_at, expires_at
+		FROM user_sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_used_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.Session{}
+	for rows.Next() {
+		var s models.Session
+		if err := rows.Scan(&s.ID, &s.UserAgent, &s.IPAddress, &s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt); err != nil {
+			return nil, err
+		}
+		s.Current = s.ID == currentID
+		res = append(res, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// IsSessionActive reports whether access tokens issued for the session are still honoured
+func (q *SessionQueries) IsSessionActive(sessionID uuid.UUID) (bool, error) {
+	var active bool
+	err := q.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM user_sessions WHERE id = $1 AND revoked_at IS NULL AND expires_at > NOW())`, sessionID).Scan(&active)
+	return active, err
+}
+
+func (q *SessionQueries) RevokeSession(sessionID, userID uuid.UUID) error {
+	res, err := q.DB.Exec(`UPDATE user_sessions SET revoked_at = NOW() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`, sessionID, userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeSessionByToken revokes the session owning the refresh token and returns its id
+func (q *SessionQueries) RevokeSessionByToken(tokenHash string) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := q.DB.QueryRow(`UPDATE user_sessions SET revoked_at = NOW() WHERE refresh_token_hash = $1 AND revoked_at IS NULL RETURNING id`, tokenHash).Scan(&id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, ErrSessionNotFound
+		}
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+// RevokeAllSessions signs the user out everywhere except keep (uuid.Nil keeps nothing) and returns the revoked ids
+func (q *SessionQueries) RevokeAllSessions(userID, keep uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.DB.Query(`UPDATE user_sessions SET revoked_at = NOW()
+		WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL
+		RETURNING id`, userID, keep)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		res = append(res, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// revokeUserSessions revokes every active session of the user inside tx and returns their ids
+func revokeUserSessions(tx *sql.Tx, userID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := tx.Query(`UPDATE user_sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL RETURNING id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		res = append(res, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
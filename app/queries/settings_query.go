@@ -0,0 +1,30 @@
+package queries
+
+import (
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+type SettingsQueries struct {
+	DB *sql.DB
+}
+
+// GetBoolSetting returns the setting as a boolean; a missing row is false
+func (q *SettingsQueries) GetBoolSetting(key string) (bool, error) {
+	var value string
+	if err := q.DB.QueryRow(`SELECT value FROM app_settings WHERE key = $1`, key).Scan(&value); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	return value == "true", nil
+}
+
+func (q *SettingsQueries) SetSetting(key, value string, updatedBy uuid.UUID) error {
+	_, err := q.DB.Exec(`INSERT INTO app_settings (key, value, updated_by, updated_at) VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, updated_by = EXCLUDED.updated_by, updated_at = NOW()`,
+		key, value, updatedBy)
+	return err
+}
@@ -2,16 +2,25 @@ package queries
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type SocialsQueries struct {
 	DB *sql.DB
 }
 
+var (
+	ErrParentCommentNotFound = errors.New("parent comment not found")
+	ErrCommentQuizNotFound   = errors.New("quiz not found")
+)
+
 func (q *SocialsQueries) HasLiked(quizID, userID string) (bool, error) {
 	var cnt int
 	idQ, err := uuid.Parse(quizID)
@@ -67,7 +76,9 @@ func (q *SocialsQueries) CountLikes(quizID string) (int, error) {
 	return cnt, nil
 }
 
-func (q *SocialsQueries) AddComment(quizID, userID, content string) (string, error) {
+// AddComment stores a comment on a quiz. A non-nil parentID makes it a reply and must belong to the same quiz.
+// Users blocked by (or blocking) the quiz owner cannot comment; callers check the quiz's visibility with CanViewQuiz.
+func (q *SocialsQueries) AddComment(quizID, userID, content string, parentID *uuid.UUID) (string, error) {
 	idQ, err := uuid.Parse(quizID)
 	if err != nil {
 		return "", err
@@ -77,13 +88,131 @@ func (q *SocialsQueries) AddComment(quizID, userID, content string) (string, err
 		return "", err
 	}
 	var commentID string
-	query := `INSERT INTO comments (quiz_id, content, commenter_by) VALUES ($1,$2,$3) RETURNING id`
-	if err := q.DB.QueryRow(query, idQ, content, uID).Scan(&commentID); err != nil {
-		return "", err
+	query := `INSERT INTO comments (quiz_id, content, commenter_by, parent_id)
+		SELECT $1, $2, $3, $4
+		FROM quizzes qz
+		WHERE qz.id = $1
+		  AND NOT ` + blockedBetween("$3::uuid", "qz.created_by") + `
+		  AND ($4::uuid IS NULL OR EXISTS (SELECT 1 FROM comments p WHERE p.id = $4 AND p.quiz_id = $1))
+		RETURNING id`
+	if err := q.DB.QueryRow(query, idQ, content, uID, parentID).Scan(&commentID); err != nil {
+		if err != sql.ErrNoRows {
+			return "", err
+		}
+		var quizExists, blocked bool
+		check := `SELECT EXISTS(SELECT 1 FROM quizzes WHERE id = $1),
+			EXISTS(SELECT 1 FROM quizzes qz WHERE qz.id = $1 AND ` + blockedBetween("$2::uuid", "qz.created_by") + `)`
+		if err := q.DB.QueryRow(check, idQ, uID).Scan(&quizExists, &blocked); err != nil {
+			return "", err
+		}
+		switch {
+		case !quizExists:
+			return "", ErrCommentQuizNotFound
+		case blocked:
+			return "", ErrUserBlocked
+		}
+		return "", ErrParentCommentNotFound
 	}
 	return commentID, nil
 }
 
+// EditComment replaces the content of the user's own comment and keeps the previous version in comment_edits
+func (q *SocialsQueries) EditComment(commentID, userID uuid.UUID, content string) error {
+	tx, err := q.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var previous string
+	err = tx.QueryRow(`SELECT content FROM comments WHERE id = $1 AND commenter_by = $2 FOR UPDATE`, commentID, userID).Scan(&previous)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("not found or not owner")
+		}
+		return err
+	}
+	if previous == content {
+		return nil
+	}
+	if _, err := tx.Exec(`INSERT INTO comment_edits (comment_id, previous_content) VALUES ($1, $2)`, commentID, previous); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE comments SET content = $1, updated_at = NOW() WHERE id = $2`, content, commentID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetVisibleCommentQuiz returns the quiz of a comment that viewerID may read, applying the same hidden and
+// block filters as the comment listings. It returns uuid.Nil when the comment is not visible.
+func (q *SocialsQueries) GetVisibleCommentQuiz(commentID, viewerID uuid.UUID) (uuid.UUID, error) {
+	var quizID uuid.UUID
+	err := q.DB.QueryRow(`SELECT c.quiz_id FROM comments c
+		WHERE c.id = $1 AND c.is_hidden = FALSE AND NOT `+blockedBetween("$2::uuid", "c.commenter_by"), commentID, viewerID).Scan(&quizID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, nil
+		}
+		return uuid.Nil, err
+	}
+	return quizID, nil
+}
+
+func (q *SocialsQueries) GetCommentHistory(commentID uuid.UUID) ([]models.CommentEdit, error) {
+	rows, err := q.DB.Query(`SELECT previous_content, edited_at FROM comment_edits WHERE comment_id = $1 ORDER BY edited_at ASC`, commentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.CommentEdit{}
+	for rows.Next() {
+		var e models.CommentEdit
+		if err := rows.Scan(&e.PreviousContent, &e.EditedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// SyncMentions makes the comment's mentions match usernames and returns the users that were newly mentioned
+func (q *SocialsQueries) SyncMentions(commentID uuid.UUID, usernames []string) ([]uuid.UUID, error) {
+	if _, err := q.DB.Exec(`DELETE FROM comment_mentions WHERE comment_id = $1 AND user_id NOT IN (SELECT uid FROM users WHERE username = ANY($2))`,
+		commentID, pq.Array(usernames)); err != nil {
+		return nil, err
+	}
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+
+	rows, err := q.DB.Query(`INSERT INTO comment_mentions (comment_id, user_id)
+		SELECT $1, uid FROM users WHERE username = ANY($2)
+		ON CONFLICT DO NOTHING
+		RETURNING user_id`, commentID, pq.Array(usernames))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		res = append(res, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
 func (q *SocialsQueries) DeleteComment(commentID, userID string) error {
 	cid, err := uuid.Parse(commentID)
 	if err != nil {
@@ -107,30 +236,114 @@ func (q *SocialsQueries) DeleteComment(commentID, userID string) error {
 	return nil
 }
 
-func (q *SocialsQueries) GetComments(quizID string, limit int) ([]models.Comment, error) {
+const commentSelect = `
+	SELECT c.id, c.quiz_id, c.parent_id, c.content, c.commenter_by, u.username, u.image_url, c.created_at, c.updated_at,
+		(SELECT COUNT(*) FROM comments r WHERE r.parent_id = c.id AND r.is_hidden = FALSE) AS reply_count,
+		COALESCE((
+			SELECT json_agg(json_build_object('id', mu.uid, 'username', mu.username))
+			FROM comment_mentions cm
+			JOIN users mu ON mu.uid = cm.user_id
+			WHERE cm.comment_id = c.id
+		), '[]') AS mentions
+	FROM comments c
+	JOIN users u ON u.uid = c.commenter_by`
+
+func scanComment(row interface{ Scan(...interface{}) error }) (*models.Comment, error) {
+	var c models.Comment
+	var parentID uuid.NullUUID
+	var imageURL sql.NullString
+	var updatedAt sql.NullTime
+	var mentions []byte
+	if err := row.Scan(&c.ID, &c.QuizID, &parentID, &c.Content, &c.CommenterBy, &c.Author.Username, &imageURL, &c.CreatedAt, &updatedAt, &c.ReplyCount, &mentions); err != nil {
+		return nil, err
+	}
+	c.Author.ID = c.CommenterBy
+	if parentID.Valid {
+		c.ParentID = &parentID.UUID
+	}
+	if imageURL.Valid {
+		c.Author.ImageURL = &imageURL.String
+	}
+	if updatedAt.Valid {
+		c.UpdatedAt = &updatedAt.Time
+		c.Edited = true
+	}
+	c.Mentions = []models.CommentAuthor{}
+	if err := json.Unmarshal(mentions, &c.Mentions); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (q *SocialsQueries) GetComment(commentID uuid.UUID) (*models.Comment, error) {
+	c, err := scanComment(q.DB.QueryRow(commentSelect+` WHERE c.id = $1`, commentID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetComments pages through a quiz's top-level comments, newest first, leaving out authors blocked by or blocking viewerID
+func (q *SocialsQueries) GetComments(quizID string, viewerID uuid.UUID, cursor *models.CommentCursor, limit int) (*models.CommentPage, error) {
 	idQ, err := uuid.Parse(quizID)
 	if err != nil {
 		return nil, err
 	}
-	base := `SELECT id, quiz_id, content, commenter_by, created_at FROM comments WHERE quiz_id = $1 ORDER BY created_at DESC`
-	if limit > 0 {
-		base += fmt.Sprintf(" LIMIT %d", limit)
+	query := commentSelect + ` WHERE c.quiz_id = $1 AND c.parent_id IS NULL AND c.is_hidden = FALSE AND NOT ` + blockedBetween("$2::uuid", "c.commenter_by")
+	args := []interface{}{idQ, viewerID}
+	if cursor != nil {
+		query += ` AND (c.created_at, c.id) < ($3, $4)`
+		args = append(args, cursor.CreatedAt, cursor.ID)
+	}
+	query += ` ORDER BY c.created_at DESC, c.id DESC`
+	return q.getCommentPage(query, args, limit)
+}
+
+// GetReplies pages through the replies to a comment, oldest first, leaving out authors blocked by or blocking viewerID
+func (q *SocialsQueries) GetReplies(parentID, viewerID uuid.UUID, cursor *models.CommentCursor, limit int) (*models.CommentPage, error) {
+	query := commentSelect + ` WHERE c.parent_id = $1 AND c.is_hidden = FALSE AND NOT ` + blockedBetween("$2::uuid", "c.commenter_by")
+	args := []interface{}{parentID, viewerID}
+	if cursor != nil {
+		query += ` AND (c.created_at, c.id) > ($3, $4)`
+		args = append(args, cursor.CreatedAt, cursor.ID)
+	}
+	query += ` ORDER BY c.created_at ASC, c.id ASC`
+	return q.getCommentPage(query, args, limit)
+}
+
+func (q *SocialsQueries) getCommentPage(query string, args []interface{}, limit int) (*models.CommentPage, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
 	}
-	rows, err := q.DB.Query(base, idQ)
+	query += fmt.Sprintf(" LIMIT %d", limit+1)
+
+	rows, err := q.DB.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	res := []models.Comment{}
 	for rows.Next() {
-		var c models.Comment
-		if err := rows.Scan(&c.ID, &c.QuizID, &c.Content, &c.CommenterBy, &c.CreatedAt); err != nil {
+		c, err := scanComment(rows)
+		if err != nil {
 			return nil, err
 		}
-		res = append(res, c)
+		res = append(res, *c)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
-	return res, nil
+
+	page := &models.CommentPage{Comments: res}
+	if len(res) > limit {
+		page.Comments = res[:limit]
+		last := page.Comments[limit-1]
+		if page.NextCursor, err = utils.EncodeCursor(models.CommentCursor{CreatedAt: last.CreatedAt, ID: last.ID}); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
 }
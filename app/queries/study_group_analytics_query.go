@@ -0,0 +1,195 @@
+package queries
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/google/uuid"
+)
+
+// GetStudyGroupAnalytics aggregates attempts made by members on quizzes assigned to the group within [from, to)
+func (q *StudyGroupQueries) GetStudyGroupAnalytics(groupID uuid.UUID, from, to time.Time, hardestLimit int) (*models.StudyGroupAnalytics, error) {
+	group, err := q.GetStudyGroup(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, nil
+	}
+
+	res := &models.StudyGroupAnalytics{
+		GroupID:     groupID,
+		From:        from,
+		To:          to,
+		MemberCount: group.MemberCount,
+	}
+
+	if res.Quizzes, err = q.getQuizAnalytics(groupID, from, to, group.MemberCount); err != nil {
+		return nil, err
+	}
+	if res.HardestQuestions, err = q.getHardestQuestions(groupID, from, to, hardestLimit); err != nil {
+		return nil, err
+	}
+	if res.MemberTrends, err = q.getMemberTrends(groupID, from, to); err != nil {
+		return nil, err
+	}
+	if res.InactiveMembers, err = q.getInactiveMembers(groupID, from, to); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (q *StudyGroupQueries) getQuizAnalytics(groupID uuid.UUID, from, to time.Time, memberCount int) ([]models.QuizAnalytics, error) {
+	query := `
+	SELECT q.id, q.title,
+		COUNT(a.id) AS attempts,
+		COUNT(DISTINCT a.user_id) AS participants,
+		COALESCE(AVG(a.score), 0) AS average_score,
+		COALESCE(AVG(a.score * 100.0 / a.total_questions), 0) AS average_percent
+	FROM quizzes q
+	LEFT JOIN attempts_quiz a ON a.quiz_id = q.id
+		AND a.submitted_at >= $2 AND a.submitted_at < $3
+		AND a.user_id IN (SELECT user_id FROM study_group_member WHERE group_id = $1)
+	WHERE q.study_group_id = $1
+	GROUP BY q.id, q.title, q.created_at
+	ORDER BY q.created_at DESC
+	`
+	rows, err := q.DB.Query(query, groupID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.QuizAnalytics{}
+	for rows.Next() {
+		var qa models.QuizAnalytics
+		if err := rows.Scan(&qa.QuizID, &qa.Title, &qa.Attempts, &qa.Participants, &qa.AverageScore, &qa.AveragePercent); err != nil {
+			return nil, err
+		}
+		if memberCount > 0 {
+			qa.CompletionRate = float64(qa.Participants) / float64(memberCount)
+		}
+		res = append(res, qa)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (q *StudyGroupQueries) getHardestQuestions(groupID uuid.UUID, from, to time.Time, limit int) ([]models.QuestionDifficulty, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	query := `
+	SELECT qq.id, qq.quiz_id, qq.question_text, COUNT(*) AS answers,
+		AVG(CASE WHEN qo.is_correct THEN 1.0 ELSE 0.0 END) AS correct_rate
+	FROM attempts_quiz_answer aa
+	JOIN attempts_quiz a ON a.id = aa.attempt_id
+	JOIN quiz_questions qq ON qq.id = aa.question_id
+	JOIN quizzes q ON q.id = qq.quiz_id
+	JOIN quiz_options qo ON qo.id = aa.selected_option_id
+	JOIN study_group_member sgm ON sgm.user_id = a.user_id AND sgm.group_id = $1
+	WHERE q.study_group_id = $1
+	  AND a.submitted_at >= $2 AND a.submitted_at < $3
+	GROUP BY qq.id, qq.quiz_id, qq.question_text
+	ORDER BY correct_rate ASC, answers DESC
+	LIMIT $4
+	`
+	rows, err := q.DB.Query(query, groupID, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.QuestionDifficulty{}
+	for rows.Next() {
+		var d models.QuestionDifficulty
+		if err := rows.Scan(&d.QuestionID, &d.QuizID, &d.QuestionText, &d.Answers, &d.CorrectRate); err != nil {
+			return nil, err
+		}
+		res = append(res, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (q *StudyGroupQueries) getMemberTrends(groupID uuid.UUID, from, to time.Time) ([]models.MemberTrend, error) {
+	query := `
+	SELECT u.uid, u.username, date_trunc('week', a.submitted_at) AS period,
+		COUNT(*) AS attempts,
+		AVG(a.score * 100.0 / a.total_questions) AS average_percent
+	FROM study_group_member sgm
+	JOIN users u ON u.uid = sgm.user_id
+	JOIN attempts_quiz a ON a.user_id = sgm.user_id
+	JOIN quizzes q ON q.id = a.quiz_id AND q.study_group_id = $1
+	WHERE sgm.group_id = $1
+	  AND a.submitted_at >= $2 AND a.submitted_at < $3
+	GROUP BY u.uid, u.username, period
+	ORDER BY u.username, period
+	`
+	rows, err := q.DB.Query(query, groupID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.MemberTrend{}
+	for rows.Next() {
+		var userID uuid.UUID
+		var username string
+		var p models.MemberTrendPoint
+		if err := rows.Scan(&userID, &username, &p.Period, &p.Attempts, &p.AveragePercent); err != nil {
+			return nil, err
+		}
+		if len(res) == 0 || res[len(res)-1].UserID != userID {
+			res = append(res, models.MemberTrend{UserID: userID, Username: username, Points: []models.MemberTrendPoint{}})
+		}
+		res[len(res)-1].Points = append(res[len(res)-1].Points, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// getInactiveMembers lists members (other than the owner) with no attempts on group quizzes inside the range
+func (q *StudyGroupQueries) getInactiveMembers(groupID uuid.UUID, from, to time.Time) ([]models.InactiveMember, error) {
+	query := `
+	SELECT u.uid, u.username, sgm.joined_at, MAX(a.submitted_at) AS last_attempt_at
+	FROM study_group_member sgm
+	JOIN study_group sg ON sg.id = sgm.group_id
+	JOIN users u ON u.uid = sgm.user_id
+	LEFT JOIN attempts_quiz a ON a.user_id = sgm.user_id
+		AND a.quiz_id IN (SELECT id FROM quizzes WHERE study_group_id = $1)
+	WHERE sgm.group_id = $1 AND sgm.user_id != sg.created_by
+	GROUP BY u.uid, u.username, sgm.joined_at
+	HAVING COUNT(a.id) FILTER (WHERE a.submitted_at >= $2 AND a.submitted_at < $3) = 0
+	ORDER BY last_attempt_at ASC NULLS FIRST
+	`
+	rows, err := q.DB.Query(query, groupID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.InactiveMember{}
+	for rows.Next() {
+		var m models.InactiveMember
+		var last sql.NullTime
+		if err := rows.Scan(&m.UserID, &m.Username, &m.JoinedAt, &last); err != nil {
+			return nil, err
+		}
+		if last.Valid {
+			m.LastAttemptAt = &last.Time
+		}
+		res = append(res, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
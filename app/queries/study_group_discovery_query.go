@@ -0,0 +1,155 @@
+package queries
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const lastActivityExpr = `GREATEST(
+		(SELECT MAX(m.joined_at) FROM study_group_member m WHERE m.group_id = sg.id),
+		(SELECT MAX(a.submitted_at) FROM attempts_quiz a JOIN quizzes qz ON qz.id = a.quiz_id WHERE qz.study_group_id = sg.id),
+		(SELECT MAX(t.last_post_at) FROM study_group_thread t WHERE t.group_id = sg.id)
+	)`
+
+func scanStudyGroupSummary(row interface{ Scan(...interface{}) error }, withFollowed bool) (*models.StudyGroupSummary, error) {
+	var s models.StudyGroupSummary
+	var last sql.NullTime
+	dest := []interface{}{&s.ID, &s.Name, &s.Description, pq.Array(&s.SubjectTags), &s.MemberCount, &s.MaxMember, &s.CreatedBy, &s.CreatedAt, &last}
+	if withFollowed {
+		dest = append(dest, &s.FollowedMembers)
+	}
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	if last.Valid {
+		s.LastActivityAt = &last.Time
+	}
+	return &s, nil
+}
+
+// DiscoverStudyGroups searches public study groups by text, tags, size and recent activity
+func (q *StudyGroupQueries) DiscoverStudyGroups(f models.StudyGroupDiscoveryFilter) ([]models.StudyGroupSummary, error) {
+	if f.Limit <= 0 {
+		f.Limit = 20
+	}
+	if f.Offset < 0 {
+		f.Offset = 0
+	}
+
+	conds := []string{}
+	args := []interface{}{}
+	addArg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if f.Query != "" {
+		p := addArg("%" + f.Query + "%")
+		conds = append(conds, fmt.Sprintf("(g.name ILIKE %s OR g.description ILIKE %s)", p, p))
+	}
+	if tags := utils.NormalizeTags(f.Tags); len(tags) > 0 {
+		conds = append(conds, fmt.Sprintf("g.subject_tags && %s", addArg(pq.Array(tags))))
+	}
+	if f.MinMembers != nil {
+		conds = append(conds, fmt.Sprintf("g.member_count >= %s", addArg(*f.MinMembers)))
+	}
+	if f.MaxMembers != nil {
+		conds = append(conds, fmt.Sprintf("g.member_count <= %s", addArg(*f.MaxMembers)))
+	}
+	if f.HasSpace {
+		conds = append(conds, "g.member_count < g.max_member")
+	}
+	if f.ActiveWithinDays > 0 {
+		conds = append(conds, fmt.Sprintf("g.last_activity_at >= NOW() - make_interval(days => %s)", addArg(f.ActiveWithinDays)))
+	}
+
+	where := ""
+	if len(conds) > 0 {
+		where = "WHERE " + strings.Join(conds, " AND ")
+	}
+
+	order := "g.last_activity_at DESC NULLS LAST, g.created_at DESC"
+	switch f.Sort {
+	case "recent":
+		order = "g.created_at DESC"
+	case "popular":
+		order = "g.member_count DESC, g.created_at DESC"
+	}
+
+	query := fmt.Sprintf(`
+	SELECT g.id, g.name, g.description, g.subject_tags, g.member_count, g.max_member, g.created_by, g.created_at, g.last_activity_at
+	FROM (
+		SELECT sg.*, %s AS last_activity_at
+		FROM study_group sg
+		WHERE sg.is_private = FALSE AND sg.is_hidden = FALSE
+	) g
+	%s
+	ORDER BY %s
+	LIMIT %s OFFSET %s`, lastActivityExpr, where, order, addArg(f.Limit), addArg(f.Offset))
+
+	rows, err := q.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.StudyGroupSummary{}
+	for rows.Next() {
+		s, err := scanStudyGroupSummary(rows, false)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// RecommendStudyGroups suggests public groups with free seats that people the user follows have joined
+func (q *StudyGroupQueries) RecommendStudyGroups(userID uuid.UUID, limit int) ([]models.StudyGroupSummary, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	query := `
+	SELECT sg.id, sg.name, sg.description, sg.subject_tags, sg.member_count, sg.max_member, sg.created_by, sg.created_at,
+		` + lastActivityExpr + ` AS last_activity_at,
+		COUNT(DISTINCT sgm.user_id) AS followed_members
+	FROM socials s
+	JOIN study_group_member sgm ON sgm.user_id = s.following
+	JOIN study_group sg ON sg.id = sgm.group_id
+	WHERE s.follower_id = $1
+	  AND sg.is_private = FALSE AND sg.is_hidden = FALSE
+	  AND sg.member_count < sg.max_member
+	  AND NOT EXISTS (SELECT 1 FROM study_group_member me WHERE me.group_id = sg.id AND me.user_id = $1)
+	GROUP BY sg.id
+	ORDER BY followed_members DESC, sg.member_count DESC
+	LIMIT $2
+	`
+	rows, err := q.DB.Query(query, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.StudyGroupSummary{}
+	for rows.Next() {
+		s, err := scanStudyGroupSummary(rows, true)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
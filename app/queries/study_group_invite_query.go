@@ -0,0 +1,225 @@
+package queries
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var (
+	ErrInviteNotUsable = errors.New("invite is expired, revoked or fully used")
+	ErrAlreadyMember   = errors.New("already a member of this study group")
+	ErrInviteNotFound  = errors.New("invite not found or already revoked")
+)
+
+const inviteColumns = `id, group_id, code, created_by, expires_at, max_uses, use_count, revoked_at, created_at`
+
+func scanInvite(row interface{ Scan(...interface{}) error }) (*models.StudyGroupInvite, error) {
+	var inv models.StudyGroupInvite
+	var expiresAt, revokedAt sql.NullTime
+	var maxUses sql.NullInt64
+	if err := row.Scan(&inv.ID, &inv.GroupID, &inv.Code, &inv.CreatedBy, &expiresAt, &maxUses, &inv.UseCount, &revokedAt, &inv.CreatedAt); err != nil {
+		return nil, err
+	}
+	if expiresAt.Valid {
+		inv.ExpiresAt = &expiresAt.Time
+	}
+	if revokedAt.Valid {
+		inv.RevokedAt = &revokedAt.Time
+	}
+	if maxUses.Valid {
+		m := int(maxUses.Int64)
+		inv.MaxUses = &m
+	}
+	return &inv, nil
+}
+
+func (q *StudyGroupQueries) CreateInvite(groupID, createdBy uuid.UUID, expiresAt *time.Time, maxUses *int) (*models.StudyGroupInvite, error) {
+	const maxAttempt = 3
+
+	for i := 0; i < maxAttempt; i++ {
+		code, err := utils.GenerateInviteCode(10)
+		if err != nil {
+			return nil, err
+		}
+
+		query := `INSERT INTO study_group_invite (group_id, code, created_by, expires_at, max_uses)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING ` + inviteColumns
+
+		inv, err := scanInvite(q.DB.QueryRow(query, groupID, code, createdBy, expiresAt, maxUses))
+		if err == nil {
+			return inv, nil
+		}
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			continue
+		}
+		return nil, err
+	}
+
+	return nil, errors.New("failed to generate unique invite code after multiple attempts")
+}
+
+func (q *StudyGroupQueries) GetInvitesForGroup(groupID uuid.UUID) ([]models.StudyGroupInvite, error) {
+	rows, err := q.DB.Query(`SELECT `+inviteColumns+` FROM study_group_invite WHERE group_id = $1 ORDER BY created_at DESC`, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.StudyGroupInvite{}
+	for rows.Next() {
+		inv, err := scanInvite(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *inv)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (q *StudyGroupQueries) GetInviteByCode(code string) (*models.StudyGroupInvite, error) {
+	inv, err := scanInvite(q.DB.QueryRow(`SELECT `+inviteColumns+` FROM study_group_invite WHERE code = $1`, code))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return inv, nil
+}
+
+func (q *StudyGroupQueries) RevokeInvite(groupID, inviteID uuid.UUID) error {
+	res, err := q.DB.Exec(`UPDATE study_group_invite SET revoked_at = NOW() WHERE id = $1 AND group_id = $2 AND revoked_at IS NULL`, inviteID, groupID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrInviteNotFound
+	}
+	return nil
+}
+
+// RotateInviteCode replaces the group's primary invite code so a leaked code stops working
+func (q *StudyGroupQueries) RotateInviteCode(groupID uuid.UUID) (string, error) {
+	const maxAttempt = 3
+
+	for i := 0; i < maxAttempt; i++ {
+		code, err := utils.GenerateInviteCode(8)
+		if err != nil {
+			return "", err
+		}
+		res, err := q.DB.Exec(`UPDATE study_group SET invite_code = $1, updated_at = $2 WHERE id = $3`, code, time.Now(), groupID)
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+				continue
+			}
+			return "", err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return "", err
+		}
+		if n == 0 {
+			return "", errors.New("study group not found")
+		}
+		return code, nil
+	}
+
+	return "", errors.New("failed to generate unique invite code after multiple attempts")
+}
+
+// JoinStudyGroupWithInvite redeems an invite and adds the user as a member in one transaction.
+// The invite is only consumed when the user was not already a member.
+func (q *StudyGroupQueries) JoinStudyGroupWithInvite(inviteID, userID uuid.UUID) (uuid.UUID, error) {
+	tx, err := q.DB.Begin()
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback()
+
+	var groupID uuid.UUID
+	err = tx.QueryRow(`
+		UPDATE study_group_invite SET use_count = use_count + 1
+		WHERE id = $1
+		  AND revoked_at IS NULL
+		  AND (expires_at IS NULL OR expires_at > NOW())
+		  AND (max_uses IS NULL OR use_count < max_uses)
+		RETURNING group_id`, inviteID).Scan(&groupID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, ErrInviteNotUsable
+		}
+		return uuid.Nil, err
+	}
+
+	res, err := tx.Exec(`INSERT INTO study_group_member (group_id, user_id, invite_id) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`, groupID, userID, inviteID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	ra, err := res.RowsAffected()
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if ra == 0 {
+		return groupID, ErrAlreadyMember
+	}
+
+	if _, err := tx.Exec(`UPDATE study_group SET member_count = member_count + 1 WHERE id = $1`, groupID); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return uuid.Nil, err
+	}
+	return groupID, nil
+}
+
+func (q *StudyGroupQueries) GetInviteAudit(groupID uuid.UUID) ([]models.StudyGroupInviteAudit, error) {
+	query := `
+	SELECT u.uid, u.username, sgm.joined_at, sgm.invite_id, sgi.code
+	FROM study_group_member sgm
+	JOIN users u ON u.uid = sgm.user_id
+	LEFT JOIN study_group_invite sgi ON sgi.id = sgm.invite_id
+	WHERE sgm.group_id = $1
+	ORDER BY sgm.joined_at ASC
+	`
+	rows, err := q.DB.Query(query, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.StudyGroupInviteAudit{}
+	for rows.Next() {
+		var a models.StudyGroupInviteAudit
+		var inviteID uuid.NullUUID
+		var code sql.NullString
+		if err := rows.Scan(&a.UserID, &a.Username, &a.JoinedAt, &inviteID, &code); err != nil {
+			return nil, err
+		}
+		if inviteID.Valid {
+			a.InviteID = &inviteID.UUID
+		}
+		if code.Valid {
+			a.InviteCode = &code.String
+		}
+		res = append(res, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -26,10 +26,10 @@ func (q *StudyGroupQueries) CreateStudyGroup(sg *models.StudyGroup) (*models.Stu
 
 		query := `
 			INSERT INTO study_group
-				(name, description, invite_code, max_member, is_private, created_by)
+				(name, description, invite_code, max_member, is_private, subject_tags, created_by)
 			VALUES
-				($1, $2, $3, $4, $5, $6)
-			RETURNING id, name, description, invite_code, member_count, max_member, is_private, created_by, created_at, updated_at
+				($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id, name, description, invite_code, member_count, max_member, is_private, subject_tags, created_by, created_at, updated_at
 		`
 
 		var created models.StudyGroup
@@ -40,6 +40,7 @@ func (q *StudyGroupQueries) CreateStudyGroup(sg *models.StudyGroup) (*models.Stu
 			inviteCode,
 			sg.MaxMember,
 			sg.IsPrivate,
+			pq.Array(utils.NormalizeTags(sg.SubjectTags)),
 			sg.CreatedBy,
 		).Scan(
 			&created.ID,
@@ -49,6 +50,7 @@ func (q *StudyGroupQueries) CreateStudyGroup(sg *models.StudyGroup) (*models.Stu
 			&created.MemberCount,
 			&created.MaxMember,
 			&created.IsPrivate,
+			pq.Array(&created.SubjectTags),
 			&created.CreatedBy,
 			&created.CreatedAt,
 			&created.UpdatedAt,
@@ -67,10 +69,10 @@ func (q *StudyGroupQueries) CreateStudyGroup(sg *models.StudyGroup) (*models.Stu
 }
 
 func (q *StudyGroupQueries) GetStudyGroup(id uuid.UUID) (*models.StudyGroup, error) {
-	query := `SELECT id, name, description, invite_code, member_count, max_member, is_private, created_by, created_at, updated_at FROM study_group WHERE id = $1`
+	query := `SELECT id, name, description, invite_code, member_count, max_member, is_private, subject_tags, created_by, created_at, updated_at FROM study_group WHERE id = $1`
 	var sg models.StudyGroup
 	row := q.DB.QueryRow(query, id)
-	if err := row.Scan(&sg.ID, &sg.Name, &sg.Description, &sg.InviteCode, &sg.MemberCount, &sg.MaxMember, &sg.IsPrivate, &sg.CreatedBy, &sg.CreatedAt, &sg.UpdatedAt); err != nil {
+	if err := row.Scan(&sg.ID, &sg.Name, &sg.Description, &sg.InviteCode, &sg.MemberCount, &sg.MaxMember, &sg.IsPrivate, pq.Array(&sg.SubjectTags), &sg.CreatedBy, &sg.CreatedAt, &sg.UpdatedAt); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
@@ -80,10 +82,10 @@ func (q *StudyGroupQueries) GetStudyGroup(id uuid.UUID) (*models.StudyGroup, err
 }
 
 func (q *StudyGroupQueries) GetStudyGroupByInviteCode(code string) (*models.StudyGroup, error) {
-	query := `SELECT id, name, description, invite_code, member_count, max_member, is_private, created_by, created_at, updated_at FROM study_group WHERE invite_code = $1`
+	query := `SELECT id, name, description, invite_code, member_count, max_member, is_private, subject_tags, created_by, created_at, updated_at FROM study_group WHERE invite_code = $1`
 	var sg models.StudyGroup
 	row := q.DB.QueryRow(query, code)
-	if err := row.Scan(&sg.ID, &sg.Name, &sg.Description, &sg.InviteCode, &sg.MemberCount, &sg.MaxMember, &sg.IsPrivate, &sg.CreatedBy, &sg.CreatedAt, &sg.UpdatedAt); err != nil {
+	if err := row.Scan(&sg.ID, &sg.Name, &sg.Description, &sg.InviteCode, &sg.MemberCount, &sg.MaxMember, &sg.IsPrivate, pq.Array(&sg.SubjectTags), &sg.CreatedBy, &sg.CreatedAt, &sg.UpdatedAt); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
@@ -93,8 +95,8 @@ func (q *StudyGroupQueries) GetStudyGroupByInviteCode(code string) (*models.Stud
 }
 
 func (q *StudyGroupQueries) UpdateStudyGroup(sg *models.StudyGroup) error {
-	query := `UPDATE study_group SET name=$1, description=$2, max_member=$3, is_private=$4, updated_at=$5 WHERE id=$6`
-	_, err := q.DB.Exec(query, sg.Name, sg.Description, sg.MaxMember, sg.IsPrivate, time.Now(), sg.ID)
+	query := `UPDATE study_group SET name=$1, description=$2, max_member=$3, is_private=$4, subject_tags=$5, updated_at=$6 WHERE id=$7`
+	_, err := q.DB.Exec(query, sg.Name, sg.Description, sg.MaxMember, sg.IsPrivate, pq.Array(utils.NormalizeTags(sg.SubjectTags)), time.Now(), sg.ID)
 	return err
 }
 
@@ -104,24 +106,26 @@ func (q *StudyGroupQueries) DeleteStudyGroup(id uuid.UUID) error {
 	return err
 }
 
-func (q *StudyGroupQueries) JoinStudyGroup(groupID, userID uuid.UUID) error {
+// JoinStudyGroup adds the user as a member and reports whether they were not one already
+func (q *StudyGroupQueries) JoinStudyGroup(groupID, userID uuid.UUID) (bool, error) {
 	res, err := q.DB.Exec(`INSERT INTO study_group_member (group_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, groupID, userID)
 	if err != nil {
-		return err
+		return false, err
 	}
 	ra, err := res.RowsAffected()
 	if err != nil {
-		return err
+		return false, err
 	}
 	if ra > 0 {
 		_, err = q.DB.Exec(`UPDATE study_group SET member_count = member_count + 1 WHERE id = $1`, groupID)
 		if err != nil {
-			return err
+			return false, err
 		}
 	}
-	return nil
+	return ra > 0, nil
 }
 
+// GetAllStudyGroups lists public study groups only; invite codes are never returned
 func (q *StudyGroupQueries) GetAllStudyGroups(limit, offset int) ([]models.StudyGroup, error) {
 	if limit <= 0 {
 		limit = 20
@@ -130,7 +134,7 @@ func (q *StudyGroupQueries) GetAllStudyGroups(limit, offset int) ([]models.Study
 		offset = 0
 	}
 
-	query := `SELECT id, name, description, invite_code, member_count, max_member, is_private, created_by, created_at, updated_at FROM study_group ORDER BY created_at DESC LIMIT $1 OFFSET $2`
+	query := `SELECT id, name, description, NULL, member_count, max_member, is_private, subject_tags, created_by, created_at, updated_at FROM study_group WHERE is_private = FALSE AND is_hidden = FALSE ORDER BY created_at DESC LIMIT $1 OFFSET $2`
 	rows, err := q.DB.Query(query, limit, offset)
 	if err != nil {
 		return nil, err
@@ -140,7 +144,7 @@ func (q *StudyGroupQueries) GetAllStudyGroups(limit, offset int) ([]models.Study
 	res := []models.StudyGroup{}
 	for rows.Next() {
 		var sg models.StudyGroup
-		if err := rows.Scan(&sg.ID, &sg.Name, &sg.Description, &sg.InviteCode, &sg.MemberCount, &sg.MaxMember, &sg.IsPrivate, &sg.CreatedBy, &sg.CreatedAt, &sg.UpdatedAt); err != nil {
+		if err := rows.Scan(&sg.ID, &sg.Name, &sg.Description, &sg.InviteCode, &sg.MemberCount, &sg.MaxMember, &sg.IsPrivate, pq.Array(&sg.SubjectTags), &sg.CreatedBy, &sg.CreatedAt, &sg.UpdatedAt); err != nil {
 			return nil, err
 		}
 		res = append(res, sg)
@@ -159,7 +163,7 @@ func (q *StudyGroupQueries) GetStudyGroupsForUser(userID uuid.UUID, limit, offse
 		offset = 0
 	}
 
-	query := `SELECT sg.id, sg.name, sg.description, sg.invite_code, sg.member_count, sg.max_member, sg.is_private, sg.created_by, sg.created_at, sg.updated_at
+	query := `SELECT sg.id, sg.name, sg.description, sg.invite_code, sg.member_count, sg.max_member, sg.is_private, sg.subject_tags, sg.created_by, sg.created_at, sg.updated_at
 	FROM study_group sg
 	JOIN study_group_member sgm ON sgm.group_id = sg.id
 	WHERE sgm.user_id = $1
@@ -175,7 +179,7 @@ func (q *StudyGroupQueries) GetStudyGroupsForUser(userID uuid.UUID, limit, offse
 	res := []models.StudyGroup{}
 	for rows.Next() {
 		var sg models.StudyGroup
-		if err := rows.Scan(&sg.ID, &sg.Name, &sg.Description, &sg.InviteCode, &sg.MemberCount, &sg.MaxMember, &sg.IsPrivate, &sg.CreatedBy, &sg.CreatedAt, &sg.UpdatedAt); err != nil {
+		if err := rows.Scan(&sg.ID, &sg.Name, &sg.Description, &sg.InviteCode, &sg.MemberCount, &sg.MaxMember, &sg.IsPrivate, pq.Array(&sg.SubjectTags), &sg.CreatedBy, &sg.CreatedAt, &sg.UpdatedAt); err != nil {
 			return nil, err
 		}
 		res = append(res, sg)
@@ -258,3 +262,45 @@ func (q *StudyGroupQueries) GetStudyGroupDetail(groupID uuid.UUID) (*models.Stud
 	}
 	return detail, nil
 }
+
+// GetMemberRole returns the user's role in the group, or an empty string when they are not a member.
+// The group creator is always reported as owner.
+func (q *StudyGroupQueries) GetMemberRole(groupID, userID uuid.UUID) (string, error) {
+	query := `
+	SELECT CASE WHEN sg.created_by = sgm.user_id THEN $3 ELSE sgm.role END
+	FROM study_group_member sgm
+	JOIN study_group sg ON sg.id = sgm.group_id
+	WHERE sgm.group_id = $1 AND sgm.user_id = $2
+	`
+	var role string
+	if err := q.DB.QueryRow(query, groupID, userID, utils.GroupRoleOwner).Scan(&role); err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+	return role, nil
+}
+
+func (q *StudyGroupQueries) IsStudyGroupMember(groupID, userID uuid.UUID) (bool, error) {
+	role, err := q.GetMemberRole(groupID, userID)
+	if err != nil {
+		return false, err
+	}
+	return role != "", nil
+}
+
+func (q *StudyGroupQueries) SetMemberRole(groupID, userID uuid.UUID, role string) error {
+	res, err := q.DB.Exec(`UPDATE study_group_member SET role = $1 WHERE group_id = $2 AND user_id = $3`, role, groupID, userID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errors.New("member not found")
+	}
+	return nil
+}
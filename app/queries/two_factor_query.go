@@ -0,0 +1,264 @@
+package queries
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/google/uuid"
+)
+
+type TwoFactorQueries struct {
+	DB *sql.DB
+}
+
+var (
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotPending     = errors.New("start the two-factor setup first")
+	ErrInvalidTwoFactorCode    = errors.New("invalid authentication code")
+	ErrTwoFactorLocked         = errors.New("too many wrong codes, try again later")
+)
+
+// GetTOTPSecret returns the user's encrypted secret and whether it has been confirmed.
+// The secret is "" when 2FA was never set up.
+func (q *TwoFactorQueries) GetTOTPSecret(userID uuid.UUID) (string, bool, error) {
+	var secret sql.NullString
+	var enabledAt sql.NullTime
+	err := q.DB.QueryRow(`SELECT totp_secret, totp_enabled_at FROM users WHERE uid = $1`, userID).Scan(&secret, &enabledAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", false, errors.New("user not found")
+		}
+		return "", false, err
+	}
+	return secret.String, enabledAt.Valid, nil
+}
+
+// SetPendingSecret stores a new unconfirmed secret, replacing an earlier unconfirmed one
+func (q *TwoFactorQueries) SetPendingSecret(userID uuid.UUID, encryptedSecret string) error {
+	res, err := q.DB.Exec(`UPDATE users SET totp_secret = $2, totp_last_step = NULL
+		WHERE uid = $1 AND totp_enabled_at IS NULL`, userID, encryptedSecret)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrTwoFactorAlreadyEnabled
+	}
+	return nil
+}
+
+func insertRecoveryCodes(tx *sql.Tx, userID uuid.UUID, codeHashes []string) error {
+	if _, err := tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	for _, h := range codeHashes {
+		if _, err := tx.Exec(`INSERT INTO user_recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnableTwoFactor confirms the pending secret with the step of the code that proved it and stores the recovery codes
+func (q *TwoFactorQueries) EnableTwoFactor(userID uuid.UUID, step int64, codeHashes []string) error {
+	tx, err := q.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`UPDATE users SET totp_enabled_at = NOW(), totp_last_step = $2, updated_at = NOW()
+		WHERE uid = $1 AND totp_secret IS NOT NULL AND totp_enabled_at IS NULL`, userID, step)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrTwoFactorNotPending
+	}
+	if err := insertRecoveryCodes(tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UseTOTPStep records the step of an accepted code. A step at or before the last used one is a replay
+// and yields ErrInvalidTwoFactorCode.
+func (q *TwoFactorQueries) UseTOTPStep(userID uuid.UUID, step int64) error {
+	res, err := q.DB.Exec(`UPDATE users SET totp_last_step = $2
+		WHERE uid = $1 AND COALESCE(totp_last_step, -1) < $2`, userID, step)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// UseRecoveryCode consumes one unused recovery code
+func (q *TwoFactorQueries) UseRecoveryCode(userID uuid.UUID, codeHash string) error {
+	res, err := q.DB.Exec(`UPDATE user_recovery_codes SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`, userID, codeHash)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// ReplaceRecoveryCodes invalidates all earlier recovery codes
+func (q *TwoFactorQueries) ReplaceRecoveryCodes(userID uuid.UUID, codeHashes []string) error {
+	tx, err := q.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := insertRecoveryCodes(tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DisableTwoFactor removes the secret and the recovery codes
+func (q *TwoFactorQueries) DisableTwoFactor(userID uuid.UUID) error {
+	tx, err := q.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL, totp_last_step = NULL, updated_at = NOW()
+		WHERE uid = $1 AND totp_secret IS NOT NULL`, userID)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrTwoFactorNotEnabled
+	}
+	if _, err := tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (q *TwoFactorQueries) GetStatus(userID uuid.UUID) (*models.TwoFactorStatus, error) {
+	s := &models.TwoFactorStatus{}
+	var enabledAt sql.NullTime
+	var role string
+	err := q.DB.QueryRow(`SELECT u.totp_enabled_at, u.totp_secret IS NOT NULL AND u.totp_enabled_at IS NULL, u.user_role,
+			(SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = u.uid AND used_at IS NULL),
+			COALESCE((SELECT value FROM app_settings WHERE key = $2), 'false') = 'true'
+		FROM users u WHERE u.uid = $1`, userID, utils.SettingAdminRequire2FA).
+		Scan(&enabledAt, &s.Pending, &role, &s.RecoveryCodesRemaining, &s.Required)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("user not found")
+		}
+		return nil, err
+	}
+	if enabledAt.Valid {
+		s.Enabled = true
+		s.EnabledAt = &enabledAt.Time
+	}
+	s.Required = s.Required && role == utils.RoleAdmin
+	return s, nil
+}
+
+// TwoFactorMissing reports whether the user is an admin without 2FA while the admin_require_2fa setting is on
+func (q *TwoFactorQueries) TwoFactorMissing(userID uuid.UUID) (bool, error) {
+	var missing bool
+	err := q.DB.QueryRow(`SELECT u.user_role = $2 AND u.totp_enabled_at IS NULL
+			AND COALESCE((SELECT value FROM app_settings WHERE key = $3), 'false') = 'true'
+		FROM users u WHERE u.uid = $1`, userID, utils.RoleAdmin, utils.SettingAdminRequire2FA).Scan(&missing)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, errors.New("user not found")
+		}
+		return false, err
+	}
+	return missing, nil
+}
+
+// CheckTwoFactorLock returns ErrTwoFactorLocked while the user is locked out after too many wrong codes
+func (q *TwoFactorQueries) CheckTwoFactorLock(userID uuid.UUID) error {
+	var locked bool
+	err := q.DB.QueryRow(`SELECT COALESCE(totp_locked_until > NOW(), FALSE) FROM users WHERE uid = $1`, userID).Scan(&locked)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("user not found")
+		}
+		return err
+	}
+	if locked {
+		return ErrTwoFactorLocked
+	}
+	return nil
+}
+
+// RecordTwoFactorFailure counts a wrong code and starts the lockout once TwoFactorMaxFailures is reached
+func (q *TwoFactorQueries) RecordTwoFactorFailure(userID uuid.UUID) error {
+	_, err := q.DB.Exec(`UPDATE users SET
+			totp_locked_until = CASE WHEN totp_failed_attempts + 1 >= $2 THEN NOW() + $3 * INTERVAL '1 second' ELSE totp_locked_until END,
+			totp_failed_attempts = CASE WHEN totp_failed_attempts + 1 >= $2 THEN 0 ELSE totp_failed_attempts + 1 END
+		WHERE uid = $1`, userID, utils.TwoFactorMaxFailures, int(utils.TwoFactorLockout.Seconds()))
+	return err
+}
+
+func (q *TwoFactorQueries) ResetTwoFactorFailures(userID uuid.UUID) error {
+	_, err := q.DB.Exec(`UPDATE users SET totp_failed_attempts = 0, totp_locked_until = NULL
+		WHERE uid = $1 AND (totp_failed_attempts > 0 OR totp_locked_until IS NOT NULL)`, userID)
+	return err
+}
+
+// IsChallengeUsed reports whether the challenge token was already exchanged for a session
+func (q *TwoFactorQueries) IsChallengeUsed(jti uuid.UUID) (bool, error) {
+	var used bool
+	err := q.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM used_two_factor_challenges WHERE jti = $1)`, jti).Scan(&used)
+	return used, err
+}
+
+// ConsumeChallenge marks the challenge token as used. Of two concurrent requests with the same token only
+// the first succeeds; the second gets ErrInvalidChallenge.
+func (q *TwoFactorQueries) ConsumeChallenge(jti, userID uuid.UUID) error {
+	if _, err := q.DB.Exec(`DELETE FROM used_two_factor_challenges WHERE expires_at < NOW()`); err != nil {
+		return err
+	}
+	res, err := q.DB.Exec(`INSERT INTO used_two_factor_challenges (jti, user_id, expires_at)
+		VALUES ($1, $2, NOW() + $3 * INTERVAL '1 second')
+		ON CONFLICT (jti) DO NOTHING`, jti, userID, int(utils.TwoFactorChallengeTTL.Seconds()))
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return utils.ErrInvalidChallenge
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package queries
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/google/uuid"
+)
+
+var ErrUserBlocked = errors.New("action not allowed, one of you has blocked the other")
+
+// blockedBetween is a SQL condition that holds when either user has blocked the other
+func blockedBetween(a, b string) string {
+	return fmt.Sprintf(`EXISTS (SELECT 1 FROM user_blocks ub WHERE (ub.blocker_id = %[1]s AND ub.blocked_id = %[2]s) OR (ub.blocker_id = %[2]s AND ub.blocked_id = %[1]s))`, a, b)
+}
+
+// BlockUser blocks blocked for blocker and removes any follow between the two
+func (q *UserQueries) BlockUser(blocker, blocked uuid.UUID) error {
+	if blocker == blocked {
+		return errors.New("cannot block yourself")
+	}
+
+	tx, err := q.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM users WHERE uid = $1)`, blocked).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("user not found")
+	}
+	if _, err := tx.Exec(`INSERT INTO user_blocks (blocker_id, blocked_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`, blocker, blocked); err != nil {
+		return err
+	}
+	query := `DELETE FROM socials WHERE (follower_id = $1 AND following = $2) OR (follower_id = $2 AND following = $1)`
+	if _, err := tx.Exec(query, blocker, blocked); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (q *UserQueries) UnblockUser(blocker, blocked uuid.UUID) error {
+	res, err := q.DB.Exec(`DELETE FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2`, blocker, blocked)
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("not blocked")
+	}
+	return nil
+}
+
+// IsBlockedEither reports whether either user has blocked the other
+func (q *UserQueries) IsBlockedEither(a, b uuid.UUID) (bool, error) {
+	var blocked bool
+	if err := q.DB.QueryRow(`SELECT `+blockedBetween("$1", "$2"), a, b).Scan(&blocked); err != nil {
+		return false, err
+	}
+	return blocked, nil
+}
+
+func (q *UserQueries) GetBlockedUsers(blocker uuid.UUID, limit, offset int) ([]models.BlockedUser, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	query := `
+	SELECT u.uid, u.username, u.image_url, b.created_at
+	FROM user_blocks b
+	JOIN users u ON u.uid = b.blocked_id
+	WHERE b.blocker_id = $1
+	ORDER BY b.created_at DESC
+	LIMIT $2 OFFSET $3`
+	rows, err := q.DB.Query(query, blocker, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.BlockedUser{}
+	for rows.Next() {
+		var b models.BlockedUser
+		var imageURL sql.NullString
+		if err := rows.Scan(&b.ID, &b.Username, &imageURL, &b.BlockedAt); err != nil {
+			return nil, err
+		}
+		if imageURL.Valid {
+			b.ImageURL = &imageURL.String
+		}
+		res = append(res, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
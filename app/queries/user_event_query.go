@@ -0,0 +1,48 @@
+package queries
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/google/uuid"
+)
+
+type UserEventQueries struct {
+	DB *sql.DB
+}
+
+// GetEventsSince returns the user's events with an id greater than cursor, oldest first
+func (q *UserEventQueries) GetEventsSince(userID uuid.UUID, cursor int64, limit int) ([]models.UserEvent, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := q.DB.Query(`SELECT id, type, payload, created_at FROM user_events WHERE user_id = $1 AND id > $2 ORDER BY id ASC LIMIT $3`, userID, cursor, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.UserEvent{}
+	for rows.Next() {
+		var e models.UserEvent
+		var payload []byte
+		if err := rows.Scan(&e.ID, &e.Type, &payload, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Payload = json.RawMessage(payload)
+		res = append(res, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (q *UserEventQueries) GetLatestEventID(userID uuid.UUID) (int64, error) {
+	var id int64
+	if err := q.DB.QueryRow(`SELECT COALESCE(MAX(id), 0) FROM user_events WHERE user_id = $1`, userID).Scan(&id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
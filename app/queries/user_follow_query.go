@@ -0,0 +1,85 @@
+package queries
+
+import (
+	"database/sql"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/google/uuid"
+)
+
+// GetFollowRelation reports whether viewer follows target and whether target follows viewer
+func (q *UserQueries) GetFollowRelation(viewer, target uuid.UUID) (following bool, followedBy bool, err error) {
+	query := `SELECT
+		EXISTS(SELECT 1 FROM socials WHERE follower_id = $1 AND following = $2),
+		EXISTS(SELECT 1 FROM socials WHERE follower_id = $2 AND following = $1)`
+	err = q.DB.QueryRow(query, viewer, target).Scan(&following, &followedBy)
+	return following, followedBy, err
+}
+
+// GetFollowers lists who follows userID, newest first. Users in a block relationship with viewer are left out.
+func (q *UserQueries) GetFollowers(userID, viewer uuid.UUID, limit, offset int) ([]models.FollowListEntry, error) {
+	return q.getFollowList(`s.following = $1`, `s.follower_id`, userID, viewer, limit, offset)
+}
+
+// GetFollowing lists who userID follows, newest first. Users in a block relationship with viewer are left out.
+func (q *UserQueries) GetFollowing(userID, viewer uuid.UUID, limit, offset int) ([]models.FollowListEntry, error) {
+	return q.getFollowList(`s.follower_id = $1`, `s.following`, userID, viewer, limit, offset)
+}
+
+func (q *UserQueries) getFollowList(match, other string, userID, viewer uuid.UUID, limit, offset int) ([]models.FollowListEntry, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	query := `
+	SELECT u.uid, u.username, u.image_url, s.followed_at,
+		EXISTS(SELECT 1 FROM socials f1 WHERE f1.follower_id = $2 AND f1.following = u.uid) AS is_following,
+		EXISTS(SELECT 1 FROM socials f2 WHERE f2.follower_id = u.uid AND f2.following = $2) AS follows_you
+	FROM socials s
+	JOIN users u ON u.uid = ` + other + `
+	WHERE ` + match + `
+	  AND NOT ` + blockedBetween("$2::uuid", "u.uid") + `
+	ORDER BY s.followed_at DESC, u.uid
+	LIMIT $3 OFFSET $4`
+	rows, err := q.DB.Query(query, userID, viewer, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.FollowListEntry{}
+	for rows.Next() {
+		var e models.FollowListEntry
+		var imageURL sql.NullString
+		if err := rows.Scan(&e.ID, &e.Username, &imageURL, &e.FollowedAt, &e.IsFollowing, &e.FollowsYou); err != nil {
+			return nil, err
+		}
+		if imageURL.Valid {
+			e.ImageURL = &imageURL.String
+		}
+		e.IsMutual = e.IsFollowing && e.FollowsYou
+		e.IsYou = e.ID == viewer
+		res = append(res, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// CountMutualFollowers counts the users viewer follows who also follow target
+func (q *UserQueries) CountMutualFollowers(viewer, target uuid.UUID) (int, error) {
+	query := `
+	SELECT COUNT(*)
+	FROM socials mine
+	JOIN socials theirs ON theirs.follower_id = mine.following AND theirs.following = $2
+	WHERE mine.follower_id = $1`
+	var cnt int
+	if err := q.DB.QueryRow(query, viewer, target).Scan(&cnt); err != nil {
+		return 0, err
+	}
+	return cnt, nil
+}
@@ -0,0 +1,120 @@
+package queries
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+var ErrUsernameTaken = errors.New("username is already taken")
+
+// UpdateProfile changes the fields that are not nil. Usernames are unique regardless of case; an empty bio clears it.
+func (q *UserQueries) UpdateProfile(userID uuid.UUID, username, bio *string) error {
+	if username != nil {
+		var taken bool
+		err := q.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM users WHERE LOWER(username) = LOWER($1) AND uid <> $2)`, *username, userID).Scan(&taken)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return ErrUsernameTaken
+		}
+	}
+
+	var bioArg interface{}
+	if bio != nil {
+		bioArg = strings.TrimSpace(*bio)
+	}
+	_, err := q.DB.Exec(`UPDATE users SET
+			username = COALESCE($1, username),
+			bio = CASE WHEN $2::text IS NULL THEN bio ELSE NULLIF($2, '') END,
+			updated_at = NOW()
+		WHERE uid = $3`, username, bioArg, userID)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrUsernameTaken
+		}
+		return err
+	}
+	return nil
+}
+
+// GetPasswordHash returns the stored hash; it is empty for accounts that only sign in with Google
+func (q *UserQueries) GetPasswordHash(userID uuid.UUID) (string, error) {
+	var hash sql.NullString
+	if err := q.DB.QueryRow(`SELECT password FROM users WHERE uid = $1`, userID).Scan(&hash); err != nil {
+		if err == sql.ErrNoRows {
+			return "", errors.New("user not found")
+		}
+		return "", err
+	}
+	return hash.String, nil
+}
+
+// UpdatePassword stores the new hash and revokes every session except keep, returning the revoked ids
+func (q *UserQueries) UpdatePassword(userID uuid.UUID, passwordHash string, keep uuid.UUID) ([]uuid.UUID, error) {
+	tx, err := q.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE users SET password = $1, updated_at = NOW() WHERE uid = $2`, passwordHash, userID); err != nil {
+		return nil, err
+	}
+	rows, err := tx.Query(`UPDATE user_sessions SET revoked_at = NOW() WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL RETURNING id`, userID, keep)
+	if err != nil {
+		return nil, err
+	}
+	revoked := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		revoked = append(revoked, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return revoked, tx.Commit()
+}
+
+// SetImageURL replaces the user's avatar URL
+func (q *UserQueries) SetImageURL(userID uuid.UUID, imageURL *string) error {
+	_, err := q.DB.Exec(`UPDATE users SET image_url = $1, updated_at = NOW() WHERE uid = $2`, imageURL, userID)
+	return err
+}
+
+// AvailableUsername returns base when it is free, otherwise base with a numeric suffix. It is used for
+// accounts created through an external sign-in, where the username is derived from the provider's profile.
+func (q *UserQueries) AvailableUsername(base string) (string, error) {
+	// leave room for the four digit suffix added on collisions
+	base = utils.SanitizeUsername(base, utils.UsernameMaxLength-4)
+	if len(base) < utils.UsernameMinLength {
+		base = "user"
+	}
+	candidate := base
+	for i := 0; i < 20; i++ {
+		if err := utils.ValidateUsername(candidate); err != nil {
+			return "", err
+		}
+		var taken bool
+		if err := q.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM users WHERE LOWER(username) = LOWER($1))`, candidate).Scan(&taken); err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s%d", base, 1000+rand.Intn(9000))
+	}
+	return "", ErrUsernameTaken
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 
 	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 )
@@ -18,8 +19,8 @@ type UserQueries struct {
 func (q *UserQueries) GetUserByID(id uuid.UUID) (models.User, error) {
 	user := models.User{}
 
-	var expPoint sql.NullString
-	query := `SELECT u.uid, u.username, u.user_role, u.email, u.password, u.exp_point, u.image_url, u.created_at, u.updated_at,
+	var expPoint, bio sql.NullString
+	query := `SELECT u.uid, u.username, u.user_role, u.email, u.password, u.exp_point, u.image_url, u.bio, u.created_at, u.updated_at,
 		COALESCE(followers.count, 0) as follower_count,
 		COALESCE(following.count, 0) as following_count
 		FROM users u
@@ -39,6 +40,7 @@ func (q *UserQueries) GetUserByID(id uuid.UUID) (models.User, error) {
 		&user.PasswordHash,
 		&expPoint,
 		&user.ImageURL,
+		&bio,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 		&user.FollowerCount,
@@ -53,6 +55,10 @@ func (q *UserQueries) GetUserByID(id uuid.UUID) (models.User, error) {
 		return user, errors.New("unable to get user, DB error")
 	}
 
+	if bio.Valid {
+		user.Bio = &bio.String
+	}
+
 	// set ExpPoints from DB exp_point if present
 	if expPoint.Valid {
 		user.ExpPoints = expPoint.String
@@ -72,16 +78,23 @@ func (q *UserQueries) GetUserByID(id uuid.UUID) (models.User, error) {
 func (q *UserQueries) GetUserByEmail(email string) (models.User, error) {
 	user := models.User{}
 
-	query := `SELECT uid, username, email, password, created_at, updated_at
+	query := `SELECT uid, username, email, password, user_role, created_at, updated_at, suspended_at, suspended_until, email_verified_at, deletion_scheduled_at, totp_enabled_at
 			  FROM users WHERE email = $1`
 
+	var suspendedAt, suspendedUntil, emailVerifiedAt, deletionScheduledAt, totpEnabledAt sql.NullTime
 	err := q.DB.QueryRow(query, email).Scan(
 		&user.ID,
 		&user.Username,
 		&user.Email,
 		&user.PasswordHash,
+		&user.UserRole,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&suspendedAt,
+		&suspendedUntil,
+		&emailVerifiedAt,
+		&deletionScheduledAt,
+		&totpEnabledAt,
 	)
 
 	if err != nil {
@@ -90,24 +103,59 @@ func (q *UserQueries) GetUserByEmail(email string) (models.User, error) {
 		}
 		return user, errors.New("unable to get user, DB error")
 	}
+	if suspendedAt.Valid {
+		user.SuspendedAt = &suspendedAt.Time
+	}
+	if suspendedUntil.Valid {
+		user.SuspendedUntil = &suspendedUntil.Time
+	}
+	if emailVerifiedAt.Valid {
+		user.EmailVerifiedAt = &emailVerifiedAt.Time
+	}
+	if deletionScheduledAt.Valid {
+		user.DeletionScheduledAt = &deletionScheduledAt.Time
+	}
+	if totpEnabledAt.Valid {
+		user.TwoFactorEnabledAt = &totpEnabledAt.Time
+	}
 
 	return user, nil
 }
 
+// GetUserRole returns the role stored for the user; it is read from the DB so a demoted admin loses access immediately
+func (q *UserQueries) GetUserRole(id uuid.UUID) (string, error) {
+	var role sql.NullString
+	if err := q.DB.QueryRow(`SELECT user_role FROM users WHERE uid = $1`, id).Scan(&role); err != nil {
+		if err == sql.ErrNoRows {
+			return "", errors.New("user not found")
+		}
+		return "", err
+	}
+	return role.String, nil
+}
+
 func (q *UserQueries) CreateUser(u *models.User) error {
-	query := `INSERT INTO users (uid, username, email, password, created_at, updated_at)
-			  VALUES ($1, $2, $3, $4, $5, $6)`
+	query := `INSERT INTO users (uid, username, email, password, user_role, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, $5, $6, $7)`
 
+	role := u.UserRole
+	if role == "" {
+		role = utils.RoleUser
+	}
 	_, err := q.DB.Exec(query,
 		u.ID,
 		u.Username,
 		u.Email,
 		u.PasswordHash,
+		role,
 		u.CreatedAt,
 		u.UpdatedAt,
 	)
 
 	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" && pqErr.Constraint == "idx_users_username_lower" {
+			return ErrUsernameTaken
+		}
 		log.Println("Error creating user:", err)
 		return errors.New("unable to create user, DB error")
 	}
@@ -116,7 +164,7 @@ func (q *UserQueries) CreateUser(u *models.User) error {
 }
 
 func (q *UserQueries) DeleteUser(id uuid.UUID) error {
-	query := `DELETE FROM users WHERE id = $1`
+	query := `DELETE FROM users WHERE uid = $1`
 
 	res, err := q.DB.Exec(query, id)
 	if err != nil {
@@ -139,13 +187,23 @@ func (q *UserQueries) FollowUser(follower uuid.UUID, following uuid.UUID) error
 		return errors.New("cannot follow yourself")
 	}
 
-	query := `INSERT INTO socials (follower_id, following) VALUES ($1, $2)`
-	if _, err := q.DB.Exec(query, follower, following); err != nil {
+	query := `INSERT INTO socials (follower_id, following)
+		SELECT $1, $2
+		WHERE NOT ` + blockedBetween("$1::uuid", "$2::uuid")
+	res, err := q.DB.Exec(query, follower, following)
+	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
 			return errors.New("already following")
 		}
 		return err
 	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrUserBlocked
+	}
 	return nil
 }
 
@@ -164,43 +222,3 @@ func (q *UserQueries) UnfollowUser(follower uuid.UUID, following uuid.UUID) erro
 	}
 	return nil
 }
-
-func (q *UserQueries) GetRecommendedUsers(userID uuid.UUID, limit int) ([]models.RecommendedUser, error) {
-	query := `
-	SELECT u.uid, u.username, u.email, COALESCE(f.follower_count,0) as follower_count
-	FROM users u
-	LEFT JOIN (
-		SELECT following as user_id, COUNT(*) as follower_count
-		FROM socials
-		GROUP BY following
-	) f ON f.user_id = u.uid
-	WHERE u.uid != $1
-	  AND u.uid NOT IN (
-		SELECT following FROM socials WHERE follower_id = $1
-	  )
-	ORDER BY follower_count DESC
-	LIMIT $2
-	`
-
-	rows, err := q.DB.Query(query, userID, limit)
-	if err != nil {
-		println("Error executing query:", err.Error())
-		return nil, err
-	}
-	defer rows.Close()
-
-	var res []models.RecommendedUser
-	for rows.Next() {
-		var r models.RecommendedUser
-		if err := rows.Scan(&r.ID, &r.Username, &r.Email, &r.FollowerCount); err != nil {
-			return nil, err
-		}
-		res = append(res, r)
-	}
-	if err := rows.Err(); err != nil {
-		println("Error iterating over rows:", err.Error())
-		return nil, err
-	}
-
-	return res, nil
-}
@@ -0,0 +1,151 @@
+package queries
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/google/uuid"
+)
+
+// recommendationQuery scores candidate users for $1. Candidates come from friends-of-friends, shared
+// study groups and overlapping quiz attempts, with the most followed users as a fallback for new accounts.
+// Already followed, blocked and suspended users are never suggested.
+const recommendationQuery = `
+WITH my_following AS (
+	SELECT following AS uid FROM socials WHERE follower_id = $1
+), fof AS (
+	SELECT s.following AS uid, COUNT(*) AS mutual_friends
+	FROM socials s
+	JOIN my_following m ON m.uid = s.follower_id
+	GROUP BY s.following
+), shared_groups AS (
+	SELECT other.user_id AS uid, COUNT(*) AS shared_groups, MIN(sg.name) AS group_name
+	FROM study_group_member mine
+	JOIN study_group_member other ON other.group_id = mine.group_id AND other.user_id <> mine.user_id
+	JOIN study_group sg ON sg.id = mine.group_id
+	WHERE mine.user_id = $1
+	GROUP BY other.user_id
+), shared_quizzes AS (
+	SELECT theirs.user_id AS uid, COUNT(DISTINCT theirs.quiz_id) AS shared_quizzes
+	FROM (SELECT DISTINCT quiz_id FROM attempts_quiz WHERE user_id = $1) mine
+	JOIN attempts_quiz theirs ON theirs.quiz_id = mine.quiz_id AND theirs.user_id <> $1
+	GROUP BY theirs.user_id
+), popularity AS (
+	SELECT following AS uid, COUNT(*) AS follower_count FROM socials GROUP BY following
+), candidates AS (
+	SELECT uid FROM fof
+	UNION SELECT uid FROM shared_groups
+	UNION SELECT uid FROM shared_quizzes
+	UNION (SELECT uid FROM popularity ORDER BY follower_count DESC LIMIT 50)
+), scored AS (
+	SELECT u.uid, u.username, u.image_url,
+		COALESCE(p.follower_count, 0) AS follower_count,
+		EXISTS(SELECT 1 FROM socials fy WHERE fy.follower_id = u.uid AND fy.following = $1) AS follows_you,
+		COALESCE(f.mutual_friends, 0) AS mutual_friends,
+		COALESCE(g.shared_groups, 0) AS shared_groups,
+		g.group_name,
+		COALESCE(t.shared_quizzes, 0) AS shared_quizzes,
+		la.last_active
+	FROM candidates c
+	JOIN users u ON u.uid = c.uid
+	LEFT JOIN fof f ON f.uid = u.uid
+	LEFT JOIN shared_groups g ON g.uid = u.uid
+	LEFT JOIN shared_quizzes t ON t.uid = u.uid
+	LEFT JOIN popularity p ON p.uid = u.uid
+	LEFT JOIN LATERAL (
+		SELECT GREATEST(
+			(SELECT MAX(a.submitted_at) FROM attempts_quiz a WHERE a.user_id = u.uid),
+			(SELECT MAX(qz.created_at) FROM quizzes qz WHERE qz.created_by = u.uid)
+		) AS last_active
+	) la ON TRUE
+	WHERE u.uid <> $1
+	  AND NOT EXISTS (SELECT 1 FROM my_following m WHERE m.uid = u.uid)
+	  AND NOT EXISTS (
+		SELECT 1 FROM user_blocks ub
+		WHERE (ub.blocker_id = $1 AND ub.blocked_id = u.uid) OR (ub.blocker_id = u.uid AND ub.blocked_id = $1)
+	  )
+	  AND (u.suspended_at IS NULL OR u.suspended_until < NOW())
+)
+SELECT uid, username, image_url, follower_count, follows_you, mutual_friends, shared_groups, group_name, shared_quizzes, last_active,
+	3.0 * mutual_friends
+	+ 2.0 * shared_groups
+	+ LEAST(shared_quizzes, 5)
+	+ CASE WHEN follows_you THEN 2 ELSE 0 END
+	+ CASE
+		WHEN last_active >= NOW() - INTERVAL '7 days' THEN 1.5
+		WHEN last_active >= NOW() - INTERVAL '30 days' THEN 0.5
+		ELSE 0
+	  END
+	+ 0.5 * LN(1 + follower_count) AS score
+FROM scored
+ORDER BY score DESC, follower_count DESC, uid
+LIMIT $2
+`
+
+// GetRecommendedUsers suggests accounts for userID to follow, ranked by social graph, shared groups,
+// overlapping quiz activity and recency. Each suggestion carries human readable reasons.
+func (q *UserQueries) GetRecommendedUsers(userID uuid.UUID, limit int) ([]models.RecommendedUser, error) {
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+
+	rows, err := q.DB.Query(recommendationQuery, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := []models.RecommendedUser{}
+	for rows.Next() {
+		var r models.RecommendedUser
+		var imageURL, groupName sql.NullString
+		var lastActive sql.NullTime
+		if err := rows.Scan(&r.ID, &r.Username, &imageURL, &r.FollowerCount, &r.FollowsYou, &r.MutualFriends,
+			&r.SharedGroups, &groupName, &r.SharedQuizzes, &lastActive, &r.Score); err != nil {
+			return nil, err
+		}
+		if imageURL.Valid {
+			r.ImageURL = &imageURL.String
+		}
+		if lastActive.Valid {
+			r.LastActiveAt = &lastActive.Time
+		}
+		r.Reasons = recommendationReasons(&r, groupName.String, time.Now())
+		res = append(res, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func recommendationReasons(r *models.RecommendedUser, groupName string, now time.Time) []string {
+	reasons := []string{}
+	if r.FollowsYou {
+		reasons = append(reasons, "follows you")
+	}
+	switch {
+	case r.MutualFriends == 1:
+		reasons = append(reasons, "1 mutual friend")
+	case r.MutualFriends > 1:
+		reasons = append(reasons, fmt.Sprintf("%d mutual friends", r.MutualFriends))
+	}
+	switch {
+	case r.SharedGroups == 1:
+		reasons = append(reasons, fmt.Sprintf("in your group %s", groupName))
+	case r.SharedGroups > 1:
+		reasons = append(reasons, fmt.Sprintf("in %s and %d other groups with you", groupName, r.SharedGroups-1))
+	}
+	if r.SharedQuizzes > 0 {
+		reasons = append(reasons, fmt.Sprintf("took %d of the same quizzes", r.SharedQuizzes))
+	}
+	if r.LastActiveAt != nil && now.Sub(*r.LastActiveAt) <= 7*24*time.Hour {
+		reasons = append(reasons, "active this week")
+	}
+	if len(reasons) == 0 {
+		reasons = append(reasons, "popular with other learners")
+	}
+	return reasons
+}
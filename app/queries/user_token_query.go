@@ -0,0 +1,156 @@
+package queries
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/google/uuid"
+)
+
+type UserTokenQueries struct {
+	DB *sql.DB
+}
+
+var (
+	ErrInvalidUserToken = errors.New("invalid or expired token")
+	ErrUserTokenTooSoon = errors.New("please wait before requesting another email")
+)
+
+// CreateUserToken stores a single-use token for purpose and invalidates the user's earlier unused ones.
+// It returns ErrUserTokenTooSoon when one was issued less than UserTokenResendInterval ago.
+func (q *UserTokenQueries) CreateUserToken(userID uuid.UUID, purpose, tokenHash string, expiresAt time.Time) error {
+	tx, err := q.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var recent bool
+	err = tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM user_tokens WHERE user_id = $1 AND purpose = $2 AND created_at > $3)`,
+		userID, purpose, time.Now().Add(-utils.UserTokenResendInterval)).Scan(&recent)
+	if err != nil {
+		return err
+	}
+	if recent {
+		return ErrUserTokenTooSoon
+	}
+
+	if _, err := tx.Exec(`UPDATE user_tokens SET used_at = NOW() WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL`, userID, purpose); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO user_tokens (user_id, purpose, token_hash, expires_at) VALUES ($1, $2, $3, $4)`,
+		userID, purpose, tokenHash, expiresAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// consumeUserToken marks the token as used and returns its user, failing when it is unknown, used or expired
+func consumeUserToken(tx *sql.Tx, purpose, tokenHash string) (uuid.UUID, error) {
+	var userID uuid.UUID
+	err := tx.QueryRow(`UPDATE user_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id`, tokenHash, purpose).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return uuid.Nil, ErrInvalidUserToken
+		}
+		return uuid.Nil, err
+	}
+	return userID, nil
+}
+
+// VerifyEmail consumes an email verification token and marks the owner's address as verified
+func (q *UserTokenQueries) VerifyEmail(tokenHash string) (uuid.UUID, error) {
+	tx, err := q.DB.Begin()
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback()
+
+	userID, err := consumeUserToken(tx, utils.TokenPurposeEmailVerification, tokenHash)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if _, err := tx.Exec(`UPDATE users SET email_verified_at = COALESCE(email_verified_at, NOW()) WHERE uid = $1`, userID); err != nil {
+		return uuid.Nil, err
+	}
+	return userID, tx.Commit()
+}
+
+// ResetPassword consumes a reset token, stores the new password hash and signs the user out everywhere.
+// Following the emailed link proves ownership of the address, so it also counts as verification.
+// The ids of the revoked sessions are returned.
+func (q *UserTokenQueries) ResetPassword(tokenHash, passwordHash string) (uuid.UUID, []uuid.UUID, error) {
+	tx, err := q.DB.Begin()
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	defer tx.Rollback()
+
+	userID, err := consumeUserToken(tx, utils.TokenPurposePasswordReset, tokenHash)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	if _, err := tx.Exec(`UPDATE users SET password = $1, email_verified_at = COALESCE(email_verified_at, NOW()), updated_at = NOW() WHERE uid = $2`,
+		passwordHash, userID); err != nil {
+		return uuid.Nil, nil, err
+	}
+	if _, err := tx.Exec(`UPDATE user_tokens SET used_at = NOW() WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL`,
+		userID, utils.TokenPurposePasswordReset); err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	rows, err := tx.Query(`UPDATE user_sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL RETURNING id`, userID)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	revoked := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return uuid.Nil, nil, err
+		}
+		revoked = append(revoked, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return uuid.Nil, nil, err
+	}
+	return userID, revoked, nil
+}
+
+// MarkEmailVerified is used when a trusted identity provider has already verified the address.
+// Whoever set a password on the still unverified account never proved they own the address, so the
+// password is cleared and the account signed out everywhere. The ids of the revoked sessions are returned.
+func (q *UserTokenQueries) MarkEmailVerified(userID uuid.UUID) ([]uuid.UUID, error) {
+	tx, err := q.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var verifiedAt sql.NullTime
+	if err := tx.QueryRow(`SELECT email_verified_at FROM users WHERE uid = $1 FOR UPDATE`, userID).Scan(&verifiedAt); err != nil {
+		return nil, err
+	}
+	if verifiedAt.Valid {
+		return nil, nil
+	}
+
+	if _, err := tx.Exec(`UPDATE users SET password = '', email_verified_at = NOW(), updated_at = NOW() WHERE uid = $1`, userID); err != nil {
+		return nil, err
+	}
+	revoked, err := revokeUserSessions(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return revoked, tx.Commit()
+}
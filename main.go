@@ -9,6 +9,10 @@ import (
 	"time"
 
 	"github.com/gilanghuda/backend-Quizzo/pkg/database"
+	"github.com/gilanghuda/backend-Quizzo/pkg/jobs"
+	"github.com/gilanghuda/backend-Quizzo/pkg/mailer"
+	"github.com/gilanghuda/backend-Quizzo/pkg/oidc"
+	"github.com/gilanghuda/backend-Quizzo/pkg/realtime"
 	"github.com/gilanghuda/backend-Quizzo/pkg/routes"
 	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
 	"github.com/gofiber/fiber/v2"
@@ -65,10 +69,28 @@ func run() error {
 		return err
 	}
 
+	realtime.DefaultHub.Start(ctx, database.ConnString(), database.DB)
+	if err := mailer.Init(); err != nil {
+		return err
+	}
+	if err := oidc.Init(); err != nil {
+		return err
+	}
+	if err := jobs.BootstrapAdmins(); err != nil {
+		return err
+	}
+	jobs.StartAccountDeletion(ctx)
+
 	routes.RegisterUserRoutes(app)
 	routes.RegisterQuizRoutes(app)
 	routes.RegisterStudyGroupRoutes(app)
 	routes.RegisterSocialsRoutes(app)
+	routes.RegisterNotificationRoutes(app)
+	routes.RegisterRealtimeRoutes(app)
+	routes.RegisterReportRoutes(app)
+	routes.RegisterCollectionRoutes(app)
+	routes.RegisterSearchRoutes(app)
+	routes.RegisterAdminRoutes(app)
 
 	errCh := make(chan error, 1)
 	go func() {
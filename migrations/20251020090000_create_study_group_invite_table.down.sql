@@ -0,0 +1,2 @@
+ALTER TABLE study_group_member DROP COLUMN IF EXISTS invite_id;
+DROP TABLE IF EXISTS study_group_invite CASCADE;
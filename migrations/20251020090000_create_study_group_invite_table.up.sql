@@ -0,0 +1,16 @@
+CREATE TABLE study_group_invite (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    group_id UUID NOT NULL REFERENCES study_group(id) ON DELETE CASCADE,
+    code VARCHAR(20) UNIQUE NOT NULL,
+    created_by UUID NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+    expires_at TIMESTAMP,
+    max_uses INTEGER CHECK (max_uses > 0),
+    use_count INTEGER NOT NULL DEFAULT 0 CHECK (use_count >= 0),
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_study_group_invite_group_id ON study_group_invite(group_id);
+
+ALTER TABLE study_group_member
+ADD COLUMN invite_id UUID REFERENCES study_group_invite(id) ON DELETE SET NULL;
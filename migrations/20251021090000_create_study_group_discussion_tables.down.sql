@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS study_group_post CASCADE;
+DROP TABLE IF EXISTS study_group_thread CASCADE;
+ALTER TABLE study_group_member DROP COLUMN IF EXISTS role;
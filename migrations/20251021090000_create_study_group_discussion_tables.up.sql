@@ -0,0 +1,31 @@
+ALTER TABLE study_group_member
+ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'member' CHECK (role IN ('member', 'moderator'));
+
+CREATE TABLE study_group_thread (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    group_id UUID NOT NULL REFERENCES study_group(id) ON DELETE CASCADE,
+    quiz_id UUID REFERENCES quizzes(id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL DEFAULT '',
+    created_by UUID NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+    is_announcement BOOLEAN NOT NULL DEFAULT FALSE,
+    is_pinned BOOLEAN NOT NULL DEFAULT FALSE,
+    post_count INTEGER NOT NULL DEFAULT 0 CHECK (post_count >= 0),
+    last_post_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(group_id, quiz_id)
+);
+
+CREATE INDEX idx_study_group_thread_group_id ON study_group_thread(group_id, is_pinned DESC, last_post_at DESC);
+
+CREATE TABLE study_group_post (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    thread_id UUID NOT NULL REFERENCES study_group_thread(id) ON DELETE CASCADE,
+    parent_id UUID REFERENCES study_group_post(id) ON DELETE CASCADE,
+    body TEXT NOT NULL,
+    created_by UUID NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_study_group_post_thread_id ON study_group_post(thread_id, created_at);
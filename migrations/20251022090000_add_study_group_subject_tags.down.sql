@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_study_group_public_created_at;
+DROP INDEX IF EXISTS idx_study_group_subject_tags;
+ALTER TABLE study_group DROP COLUMN IF EXISTS subject_tags;
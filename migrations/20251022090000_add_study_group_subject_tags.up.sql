@@ -0,0 +1,5 @@
+ALTER TABLE study_group
+ADD COLUMN subject_tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX idx_study_group_subject_tags ON study_group USING GIN (subject_tags);
+CREATE INDEX idx_study_group_public_created_at ON study_group(created_at DESC) WHERE is_private = FALSE;
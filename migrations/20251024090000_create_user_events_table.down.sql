@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS quizzes_publish_feed_event ON quizzes;
+DROP TRIGGER IF EXISTS notifications_publish_event ON notifications;
+DROP FUNCTION IF EXISTS publish_feed_quiz_event();
+DROP FUNCTION IF EXISTS publish_notification_event();
+DROP TABLE IF EXISTS user_events CASCADE;
+DROP FUNCTION IF EXISTS notify_user_event();
@@ -0,0 +1,56 @@
+CREATE TABLE user_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_user_events_user_id ON user_events(user_id, id);
+CREATE INDEX idx_user_events_created_at ON user_events(created_at);
+
+-- wake up listening API instances whenever an event is stored
+CREATE OR REPLACE FUNCTION notify_user_event() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('user_events', json_build_object('id', NEW.id, 'user_id', NEW.user_id)::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER user_events_notify
+AFTER INSERT ON user_events
+FOR EACH ROW EXECUTE FUNCTION notify_user_event();
+
+-- every in-app notification (follow, like, comment, group events) is pushed live
+CREATE OR REPLACE FUNCTION publish_notification_event() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO user_events (user_id, type, payload)
+    VALUES (NEW.user_id, 'notification', row_to_json(NEW)::jsonb);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER notifications_publish_event
+AFTER INSERT ON notifications
+FOR EACH ROW EXECUTE FUNCTION publish_notification_event();
+
+-- new quizzes show up in followers' feeds
+CREATE OR REPLACE FUNCTION publish_feed_quiz_event() RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO user_events (user_id, type, payload)
+    SELECT s.follower_id, 'feed_quiz', json_build_object(
+        'quiz_id', NEW.id,
+        'title', NEW.title,
+        'difficulty', NEW.difficulty_level,
+        'created_by', NEW.created_by,
+        'created_at', NEW.created_at
+    )::jsonb
+    FROM socials s
+    WHERE s.following = NEW.created_by;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER quizzes_publish_feed_event
+AFTER INSERT ON quizzes
+FOR EACH ROW EXECUTE FUNCTION publish_feed_quiz_event();
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_attempts_quiz_user_submitted_at;
+DROP INDEX IF EXISTS idx_quizzes_created_by_created_at;
+ALTER TABLE quizzes DROP COLUMN IF EXISTS assigned_at;
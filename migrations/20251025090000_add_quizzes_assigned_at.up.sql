@@ -0,0 +1,7 @@
+ALTER TABLE quizzes
+ADD COLUMN assigned_at TIMESTAMP;
+
+UPDATE quizzes SET assigned_at = created_at WHERE study_group_id IS NOT NULL;
+
+CREATE INDEX idx_quizzes_created_by_created_at ON quizzes(created_by, created_at DESC);
+CREATE INDEX idx_attempts_quiz_user_submitted_at ON attempts_quiz(user_id, submitted_at DESC);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS comment_mentions CASCADE;
+DROP TABLE IF EXISTS comment_edits CASCADE;
+DROP INDEX IF EXISTS idx_comments_parent_id;
+DROP INDEX IF EXISTS idx_comments_quiz_created_at;
+ALTER TABLE comments DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE comments DROP COLUMN IF EXISTS parent_id;
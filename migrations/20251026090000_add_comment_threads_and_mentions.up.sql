@@ -0,0 +1,21 @@
+ALTER TABLE comments
+ADD COLUMN parent_id UUID REFERENCES comments(id) ON DELETE CASCADE,
+ADD COLUMN updated_at TIMESTAMP;
+
+CREATE INDEX idx_comments_quiz_created_at ON comments(quiz_id, created_at DESC) WHERE parent_id IS NULL;
+CREATE INDEX idx_comments_parent_id ON comments(parent_id, created_at);
+
+CREATE TABLE comment_edits (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    comment_id UUID NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    previous_content TEXT NOT NULL,
+    edited_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_comment_edits_comment_id ON comment_edits(comment_id, edited_at);
+
+CREATE TABLE comment_mentions (
+    comment_id UUID NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+    PRIMARY KEY (comment_id, user_id)
+);
@@ -0,0 +1,7 @@
+ALTER TABLE users DROP COLUMN IF EXISTS user_role;
+ALTER TABLE users DROP COLUMN IF EXISTS suspended_until;
+ALTER TABLE users DROP COLUMN IF EXISTS suspended_at;
+ALTER TABLE study_group DROP COLUMN IF EXISTS is_hidden;
+ALTER TABLE comments DROP COLUMN IF EXISTS is_hidden;
+ALTER TABLE quizzes DROP COLUMN IF EXISTS is_hidden;
+DROP TABLE IF EXISTS reports CASCADE;
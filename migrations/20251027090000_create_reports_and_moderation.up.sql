@@ -0,0 +1,29 @@
+CREATE TABLE reports (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    reporter_id UUID NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+    target_type VARCHAR(20) NOT NULL CHECK (target_type IN ('quiz', 'comment', 'user', 'study_group')),
+    target_id UUID NOT NULL,
+    reason VARCHAR(50) NOT NULL,
+    details TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'dismissed', 'actioned')),
+    resolution_action VARCHAR(20),
+    resolution_note TEXT,
+    resolved_by UUID REFERENCES users(uid) ON DELETE SET NULL,
+    resolved_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_reports_status_created_at ON reports(status, created_at);
+CREATE INDEX idx_reports_target ON reports(target_type, target_id);
+CREATE UNIQUE INDEX idx_reports_open_unique ON reports(reporter_id, target_type, target_id) WHERE status = 'open';
+
+ALTER TABLE quizzes ADD COLUMN is_hidden BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE comments ADD COLUMN is_hidden BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE study_group ADD COLUMN is_hidden BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE users
+ADD COLUMN suspended_at TIMESTAMP,
+ADD COLUMN suspended_until TIMESTAMP;
+
+-- the moderation queue is restricted to admins
+ALTER TABLE users ADD COLUMN IF NOT EXISTS user_role VARCHAR(25) DEFAULT 'user';
@@ -0,0 +1,9 @@
+CREATE TABLE user_blocks (
+    blocker_id UUID NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+    blocked_id UUID NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (blocker_id, blocked_id),
+    CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX idx_user_blocks_blocked_id ON user_blocks(blocked_id);
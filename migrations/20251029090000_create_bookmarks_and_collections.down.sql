@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS collection_runs CASCADE;
+DROP TABLE IF EXISTS collection_items CASCADE;
+DROP TABLE IF EXISTS collections CASCADE;
+DROP TABLE IF EXISTS bookmarks CASCADE;
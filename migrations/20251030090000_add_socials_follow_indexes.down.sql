@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_socials_follower_followed_at;
+DROP INDEX IF EXISTS idx_socials_following_followed_at;
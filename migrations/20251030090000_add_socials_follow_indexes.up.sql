@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS idx_socials_following_followed_at ON socials(following, followed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_socials_follower_followed_at ON socials(follower_id, followed_at DESC);
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_study_group_name_trgm;
+DROP INDEX IF EXISTS idx_study_group_search;
+DROP INDEX IF EXISTS idx_users_username_trgm;
+DROP INDEX IF EXISTS idx_quizzes_title_trgm;
+DROP INDEX IF EXISTS idx_quizzes_search_vector;
+DROP TRIGGER IF EXISTS quiz_questions_search_vector_update ON quiz_questions;
+DROP FUNCTION IF EXISTS quiz_questions_search_vector_trigger();
+DROP TRIGGER IF EXISTS quizzes_search_vector_update ON quizzes;
+DROP FUNCTION IF EXISTS quizzes_search_vector_trigger();
+DROP FUNCTION IF EXISTS quiz_search_vector(UUID, TEXT, TEXT);
+ALTER TABLE quizzes DROP COLUMN IF EXISTS search_vector;
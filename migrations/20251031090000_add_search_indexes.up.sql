@@ -0,0 +1,51 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- quizzes.search_vector covers the title (A), description (B) and every question text (C)
+ALTER TABLE quizzes ADD COLUMN search_vector tsvector;
+
+CREATE OR REPLACE FUNCTION quiz_search_vector(p_quiz_id UUID, p_title TEXT, p_description TEXT) RETURNS tsvector AS $$
+    SELECT setweight(to_tsvector('simple', COALESCE(p_title, '')), 'A')
+        || setweight(to_tsvector('simple', COALESCE(p_description, '')), 'B')
+        || setweight(to_tsvector('simple', COALESCE((
+            SELECT string_agg(qq.question_text, ' ') FROM quiz_questions qq WHERE qq.quiz_id = p_quiz_id
+        ), '')), 'C');
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION quizzes_search_vector_trigger() RETURNS trigger AS $$
+BEGIN
+    NEW.search_vector := quiz_search_vector(NEW.id, NEW.title, NEW.description);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER quizzes_search_vector_update
+BEFORE INSERT OR UPDATE OF title, description ON quizzes
+FOR EACH ROW EXECUTE FUNCTION quizzes_search_vector_trigger();
+
+CREATE OR REPLACE FUNCTION quiz_questions_search_vector_trigger() RETURNS trigger AS $$
+DECLARE
+    target UUID;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        target := OLD.quiz_id;
+    ELSE
+        target := NEW.quiz_id;
+    END IF;
+    UPDATE quizzes q SET search_vector = quiz_search_vector(q.id, q.title, q.description) WHERE q.id = target;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER quiz_questions_search_vector_update
+AFTER INSERT OR UPDATE OF question_text OR DELETE ON quiz_questions
+FOR EACH ROW EXECUTE FUNCTION quiz_questions_search_vector_trigger();
+
+UPDATE quizzes q SET search_vector = quiz_search_vector(q.id, q.title, q.description);
+
+CREATE INDEX idx_quizzes_search_vector ON quizzes USING GIN (search_vector);
+CREATE INDEX idx_quizzes_title_trgm ON quizzes USING GIN (title gin_trgm_ops);
+CREATE INDEX idx_users_username_trgm ON users USING GIN (username gin_trgm_ops);
+CREATE INDEX idx_study_group_search ON study_group USING GIN (
+    (setweight(to_tsvector('simple', name), 'A') || setweight(to_tsvector('simple', COALESCE(description, '')), 'B'))
+);
+CREATE INDEX idx_study_group_name_trgm ON study_group USING GIN (name gin_trgm_ops);
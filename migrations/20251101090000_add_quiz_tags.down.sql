@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_quizzes_subject;
+DROP INDEX IF EXISTS idx_quizzes_tags;
+ALTER TABLE quizzes DROP COLUMN IF EXISTS tags;
+ALTER TABLE quizzes DROP COLUMN IF EXISTS subject;
@@ -0,0 +1,6 @@
+ALTER TABLE quizzes
+ADD COLUMN subject VARCHAR(50) NOT NULL DEFAULT 'other',
+ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX idx_quizzes_tags ON quizzes USING GIN (tags);
+CREATE INDEX idx_quizzes_subject ON quizzes(subject);
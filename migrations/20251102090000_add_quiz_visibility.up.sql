@@ -0,0 +1,5 @@
+ALTER TABLE quizzes
+ADD COLUMN visibility VARCHAR(20) NOT NULL DEFAULT 'public' CHECK (visibility IN ('public', 'unlisted', 'followers', 'group')),
+ADD COLUMN share_token VARCHAR(32) UNIQUE;
+
+CREATE INDEX idx_quizzes_visibility ON quizzes(visibility);
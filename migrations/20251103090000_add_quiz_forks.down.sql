@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_quizzes_forked_from;
+ALTER TABLE quizzes DROP COLUMN IF EXISTS allow_forking;
+ALTER TABLE quizzes DROP COLUMN IF EXISTS forked_from;
@@ -0,0 +1,5 @@
+ALTER TABLE quizzes
+ADD COLUMN forked_from UUID REFERENCES quizzes(id) ON DELETE SET NULL,
+ADD COLUMN allow_forking BOOLEAN NOT NULL DEFAULT TRUE;
+
+CREATE INDEX idx_quizzes_forked_from ON quizzes(forked_from);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_users_role;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_user_role_check;
+ALTER TABLE users ALTER COLUMN user_role DROP NOT NULL, ALTER COLUMN user_role DROP DEFAULT;
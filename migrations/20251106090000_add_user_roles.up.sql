@@ -0,0 +1,9 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS user_role VARCHAR(25);
+UPDATE users SET user_role = 'user' WHERE user_role IS NULL OR user_role NOT IN ('user', 'admin');
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_user_role_check;
+ALTER TABLE users
+ALTER COLUMN user_role SET DEFAULT 'user',
+ALTER COLUMN user_role SET NOT NULL,
+ADD CONSTRAINT users_user_role_check CHECK (user_role IN ('user', 'admin'));
+
+CREATE INDEX IF NOT EXISTS idx_users_role ON users(user_role) WHERE user_role <> 'user';
@@ -0,0 +1,14 @@
+ALTER TABLE users ADD COLUMN bio VARCHAR(500);
+
+-- usernames that only differ by case would break the unique index; the oldest account keeps its name
+-- and the others get a suffix derived from their id
+WITH ranked AS (
+    SELECT uid, ROW_NUMBER() OVER (PARTITION BY LOWER(username) ORDER BY created_at, uid) AS rn
+    FROM users
+)
+UPDATE users u
+SET username = LEFT(u.username, 23) || '_' || LEFT(REPLACE(u.uid::text, '-', ''), 6)
+FROM ranked r
+WHERE r.uid = u.uid AND r.rn > 1;
+
+CREATE UNIQUE INDEX idx_users_username_lower ON users(LOWER(username));
@@ -0,0 +1,4 @@
+DELETE FROM users WHERE uid = '00000000-0000-0000-0000-000000000000';
+DROP INDEX IF EXISTS idx_users_deletion_scheduled;
+ALTER TABLE users DROP COLUMN IF EXISTS anonymize_on_delete;
+ALTER TABLE users DROP COLUMN IF EXISTS deletion_scheduled_at;
@@ -0,0 +1,10 @@
+ALTER TABLE users
+ADD COLUMN deletion_scheduled_at TIMESTAMP,
+ADD COLUMN anonymize_on_delete BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX idx_users_deletion_scheduled ON users(deletion_scheduled_at) WHERE deletion_scheduled_at IS NOT NULL;
+
+-- placeholder owner for quizzes kept after their author deleted the account; it can never sign in
+INSERT INTO users (uid, username, email, password, user_role, suspended_at)
+VALUES ('00000000-0000-0000-0000-000000000000', 'deleted_user', 'deleted-user@quizzo.invalid', NULL, 'user', NOW())
+ON CONFLICT DO NOTHING;
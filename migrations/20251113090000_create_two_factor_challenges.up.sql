@@ -0,0 +1,9 @@
+-- challenge tokens that were already exchanged for a session; kept until the token would have expired
+CREATE TABLE used_two_factor_challenges (
+    jti UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(uid) ON DELETE CASCADE,
+    used_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_used_two_factor_challenges_expires ON used_two_factor_challenges(expires_at);
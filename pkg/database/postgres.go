@@ -11,18 +11,23 @@ import (
 
 var DB *sql.DB
 
-func InitDB() (*sql.DB, error) {
+// ConnString builds the lib/pq connection string from the DB_DOCKER_* environment variables
+func ConnString() string {
 	host := os.Getenv("DB_DOCKER_HOST")
 	port := os.Getenv("DB_DOCKER_PORT")
 	user := os.Getenv("DB_DOCKER_USER")
 	password := os.Getenv("DB_DOCKER_PASSWORD")
 	database := os.Getenv("DB_DOCKER_NAME")
 
-	log.Printf("DB_DOCKER_HOST=%s DB_DOCKER_PORT=%s DB_DOCKER_USER=%s DB_DOCKER_PASSWORD=%s DB_DOCKER_NAME=%s",
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		host, port, user, password, database)
+}
 
-	psqlInfo := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		host, port, user, password, database)
+func InitDB() (*sql.DB, error) {
+	log.Printf("DB_DOCKER_HOST=%s DB_DOCKER_PORT=%s DB_DOCKER_USER=%s DB_DOCKER_PASSWORD=%s DB_DOCKER_NAME=%s",
+		os.Getenv("DB_DOCKER_HOST"), os.Getenv("DB_DOCKER_PORT"), os.Getenv("DB_DOCKER_USER"), os.Getenv("DB_DOCKER_PASSWORD"), os.Getenv("DB_DOCKER_NAME"))
+
+	psqlInfo := ConnString()
 
 	var err error
 	DB, err = sql.Open("postgres", psqlInfo)
@@ -0,0 +1,51 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/gilanghuda/backend-Quizzo/app/queries"
+	"github.com/gilanghuda/backend-Quizzo/pkg/database"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	accountDeletionInterval = time.Hour
+	accountDeletionBatch    = 50
+)
+
+// StartAccountDeletion purges accounts whose deletion grace period is over, once at start and then
+// every accountDeletionInterval until ctx is done
+func StartAccountDeletion(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(accountDeletionInterval)
+		defer ticker.Stop()
+		for {
+			purgeDueAccounts()
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func purgeDueAccounts() {
+	uq := queries.UserQueries{DB: database.DB}
+	ids, err := uq.GetDueDeletions(accountDeletionBatch)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list accounts due for deletion")
+		return
+	}
+	for _, id := range ids {
+		purged, err := uq.PurgeUser(id)
+		if err != nil {
+			log.Error().Err(err).Str("user_id", id.String()).Msg("failed to delete account")
+			continue
+		}
+		if purged {
+			log.Info().Str("user_id", id.String()).Msg("account deleted")
+		}
+	}
+}
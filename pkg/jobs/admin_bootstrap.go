@@ -0,0 +1,33 @@
+package jobs
+
+import (
+	"os"
+	"strings"
+
+	"github.com/gilanghuda/backend-Quizzo/app/queries"
+	"github.com/gilanghuda/backend-Quizzo/pkg/database"
+	"github.com/rs/zerolog/log"
+)
+
+// BootstrapAdmins promotes the accounts listed in ADMIN_EMAILS (comma separated) at startup. The
+// accounts must exist and have a verified email; remove them from the variable once set up, because
+// an admin demoted through the API would otherwise be promoted again on the next start.
+func BootstrapAdmins() error {
+	var emails []string
+	for _, e := range strings.Split(os.Getenv("ADMIN_EMAILS"), ",") {
+		if e = strings.ToLower(strings.TrimSpace(e)); e != "" {
+			emails = append(emails, e)
+		}
+	}
+	if len(emails) == 0 {
+		return nil
+	}
+
+	q := queries.AdminQueries{DB: database.DB}
+	n, err := q.BootstrapAdmins(emails)
+	if err != nil {
+		return err
+	}
+	log.Info().Int64("promoted", n).Int("listed", len(emails)).Msg("admin bootstrap done")
+	return nil
+}
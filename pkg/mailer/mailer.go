@@ -0,0 +1,69 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Message is a rendered email ready to be sent
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer delivers messages. Implementations must be safe for concurrent use.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Default is the mailer used by the controllers. It is set by Init; until then messages are only
+// logged without their body.
+var Default Mailer = &LogMailer{}
+
+// Init picks the mailer from MAIL_DRIVER: "smtp" uses the SMTP_* variables, "file" writes every
+// message to MAIL_DIR and "log" only logs it. The file and log drivers are meant for development
+// and tests. The driver must be set explicitly so a typo cannot silently route reset links into
+// the logs; the log driver includes bodies only when MAIL_LOG_BODY=true.
+func Init() error {
+	driver := strings.ToLower(strings.TrimSpace(os.Getenv("MAIL_DRIVER")))
+	switch driver {
+	case "smtp":
+		if os.Getenv("SMTP_HOST") == "" {
+			return errors.New("MAIL_DRIVER is smtp but SMTP_HOST is not set")
+		}
+		Default = &SMTPMailer{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("MAIL_FROM"),
+		}
+	case "file":
+		dir := os.Getenv("MAIL_DIR")
+		if dir == "" {
+			dir = "tmp/mail"
+		}
+		Default = &FileMailer{Dir: dir}
+	case "log":
+		Default = &LogMailer{ShowBody: os.Getenv("MAIL_LOG_BODY") == "true"}
+	default:
+		return fmt.Errorf("unknown MAIL_DRIVER %q, use smtp, file or log", driver)
+	}
+	log.Info().Str("driver", driver).Msg("mailer initialized")
+	return nil
+}
+
+// AppURL is the base URL of the frontend that links in emails point to
+func AppURL() string {
+	if u := os.Getenv("APP_URL"); u != "" {
+		return strings.TrimRight(u, "/")
+	}
+	return "http://localhost:3000"
+}
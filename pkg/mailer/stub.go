@@ -0,0 +1,49 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// LogMailer only logs outgoing messages. The body carries single-use links, so it is left out
+// unless ShowBody is set for local development.
+type LogMailer struct {
+	ShowBody bool
+}
+
+func (m *LogMailer) Send(_ context.Context, msg Message) error {
+	ev := log.Info().Str("to", msg.To).Str("subject", msg.Subject)
+	if m.ShowBody {
+		ev = ev.Str("body", msg.Text)
+	}
+	ev.Msg("email (log mailer)")
+	return nil
+}
+
+// FileMailer writes every message as an .eml file into Dir
+type FileMailer struct {
+	Dir string
+}
+
+func (m *FileMailer) Send(_ context.Context, msg Message) error {
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+	body, err := buildMIME("noreply@localhost", msg)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%d_%s.eml", time.Now().UnixNano(), strings.NewReplacer("@", "_at_", "/", "_").Replace(msg.To))
+	path := filepath.Join(m.Dir, name)
+	if err := os.WriteFile(path, body, 0o644); err != nil {
+		return err
+	}
+	log.Info().Str("to", msg.To).Str("subject", msg.Subject).Str("path", path).Msg("email written to file")
+	return nil
+}
@@ -3,22 +3,35 @@ package middleware
 import (
 	"os"
 	"strings"
+	"time"
 
+	"github.com/gilanghuda/backend-Quizzo/app/models"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog/log"
 )
 
+// tokenFromRequest reads the bearer token from the Authorization header, falling back to the X-API-Key
+// header and then the token cookie
+func tokenFromRequest(c *fiber.Ctx) string {
+	if key := c.Get("X-API-Key"); key != "" {
+		return key
+	}
+	authHeader := c.Get("Authorization")
+	if authHeader != "" && strings.HasPrefix(authHeader, "Bearer ") {
+		return strings.TrimPrefix(authHeader, "Bearer ")
+	}
+	return c.Cookies("token")
+}
+
+// JWTProtected requires a valid access token bound to an active session. A personal API key is accepted
+// in its place, but only gets through to handlers on routes guarded by RequireScope.
 func JWTProtected() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		var tokenString string
-
-		authHeader := c.Get("Authorization")
-		if authHeader != "" && strings.HasPrefix(authHeader, "Bearer ") {
-			tokenString = strings.TrimPrefix(authHeader, "Bearer ")
-		}
-
-		if tokenString == "" {
-			tokenString = c.Cookies("token")
+		tokenString := tokenFromRequest(c)
+		if utils.IsAPIKey(tokenString) {
+			return authenticateAPIKey(c, tokenString)
 		}
 
 		secret := os.Getenv("JWT_SECRET")
@@ -32,19 +45,59 @@ func JWTProtected() fiber.Handler {
 			return []byte(secret), nil
 		})
 		if err != nil || !token.Valid {
-			println("Token parsing error:", err.Error())
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": "Invalid or expired token",
 			})
 		}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			c.Locals("user", claims)
-		} else {
+		claims, ok := token.Claims.(jwt.MapClaims)
+		if !ok {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": "Invalid token claims in middleware",
 			})
 		}
+		if !sessionActive(claims) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Session has been revoked",
+			})
+		}
+		c.Locals("user", claims)
+		return c.Next()
+	}
+}
+
+// JWTOptional sets the user claims like JWTProtected when a valid token is sent, and lets
+// anonymous requests through otherwise. Handlers use utils.ExtractUserID to tell the two apart.
+// A valid API key only counts on routes that declare a scope with RequireScope.
+func JWTOptional() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tokenString := tokenFromRequest(c)
+		secret := os.Getenv("JWT_SECRET")
+		if tokenString == "" || secret == "" {
+			return c.Next()
+		}
+		if utils.IsAPIKey(tokenString) {
+			p, err := lookupAPIKey(c, tokenString)
+			if err != nil {
+				log.Error().Err(err).Msg("failed to check api key")
+			}
+			if p != nil {
+				u := models.User{SuspendedAt: p.SuspendedAt, SuspendedUntil: p.SuspendedUntil}
+				if !u.IsSuspended(time.Now()) {
+					c.Locals(apiKeyLocal, p)
+				}
+			}
+			return c.Next()
+		}
+
+		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+			return []byte(secret), nil
+		})
+		if err == nil && token.Valid {
+			if claims, ok := token.Claims.(jwt.MapClaims); ok && sessionActive(claims) {
+				c.Locals("user", claims)
+			}
+		}
 		return c.Next()
 	}
 }
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"github.com/gilanghuda/backend-Quizzo/app/queries"
+	"github.com/gilanghuda/backend-Quizzo/pkg/database"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+)
+
+// RequireRole lets the request through only when the caller's stored role is one of roles. The role is
+// read from the database rather than the token, so a demoted user loses access immediately.
+// Admins are also refused while the admin_require_2fa setting is on and they have not enrolled in 2FA.
+// It must run after JWTProtected; the role is kept in Locals("role") for the handlers.
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := utils.ExtractUserID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		}
+		uq := queries.UserQueries{DB: database.DB}
+		role, err := uq.GetUserRole(userID)
+		if err != nil {
+			log.Error().Err(err).Str("user_id", userID.String()).Msg("failed to get user role")
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "forbidden"})
+		}
+		for _, r := range roles {
+			if role == r {
+				if role == utils.RoleAdmin {
+					tq := queries.TwoFactorQueries{DB: database.DB}
+					missing, err := tq.TwoFactorMissing(userID)
+					if err != nil {
+						log.Error().Err(err).Str("user_id", userID.String()).Msg("failed to check two-factor enrollment")
+						return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "forbidden"})
+					}
+					if missing {
+						return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+							"error":                     "administrators must enable two-factor authentication",
+							"two_factor_setup_required": true,
+						})
+					}
+				}
+				c.Locals("role", role)
+				return c.Next()
+			}
+		}
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "insufficient role"})
+	}
+}
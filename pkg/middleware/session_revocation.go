@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gilanghuda/backend-Quizzo/app/queries"
+	"github.com/gilanghuda/backend-Quizzo/pkg/database"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+// sessionCheckTTL bounds how long another instance may keep honouring a revoked session's access tokens
+const sessionCheckTTL = 15 * time.Second
+
+type sessionStatus struct {
+	active    bool
+	checkedAt time.Time
+}
+
+var (
+	sessionMu    sync.Mutex
+	sessionCache = map[uuid.UUID]sessionStatus{}
+)
+
+// sessionActive reports whether the session named by the token's sid claim has not been revoked.
+// Tokens without a session are refused.
+func sessionActive(claims jwt.MapClaims) bool {
+	sidStr, _ := claims["sid"].(string)
+	sid, err := uuid.Parse(sidStr)
+	if err != nil {
+		return false
+	}
+	return SessionActive(sid)
+}
+
+// SessionActive reports whether the session has not been revoked, using the per-instance cache.
+// Long-lived connections call it to notice a sign-out after they were authenticated.
+func SessionActive(sid uuid.UUID) bool {
+	sessionMu.Lock()
+	st, ok := sessionCache[sid]
+	sessionMu.Unlock()
+	if ok && time.Since(st.checkedAt) < sessionCheckTTL {
+		return st.active
+	}
+
+	q := queries.SessionQueries{DB: database.DB}
+	active, err := q.IsSessionActive(sid)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sid.String()).Msg("failed to check session")
+		return false
+	}
+
+	sessionMu.Lock()
+	if len(sessionCache) > 10000 {
+		sessionCache = map[uuid.UUID]sessionStatus{}
+	}
+	sessionCache[sid] = sessionStatus{active: active, checkedAt: time.Now()}
+	sessionMu.Unlock()
+	return active
+}
+
+// ForgetSessions marks sessions as revoked on this instance right away instead of waiting for the cache to expire
+func ForgetSessions(ids ...uuid.UUID) {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	now := time.Now()
+	for _, id := range ids {
+		sessionCache[id] = sessionStatus{active: false, checkedAt: now}
+	}
+}
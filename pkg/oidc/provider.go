@@ -0,0 +1,188 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const discoveryTTL = time.Hour
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+var ErrUnknownProvider = errors.New("unknown identity provider")
+
+// Provider is one configured OpenID Connect identity provider
+type Provider struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	mu        sync.Mutex
+	meta      *discovery
+	fetchedAt time.Time
+	jwks      *keySet
+}
+
+// discovery is the subset of /.well-known/openid-configuration that the login flow needs
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Registry holds the providers configured through the environment
+type Registry struct {
+	providers map[string]*Provider
+}
+
+// Default is loaded by Init and used by the controllers
+var Default = &Registry{providers: map[string]*Provider{}}
+
+// Init reads OIDC_PROVIDERS, a comma separated list of names, and for every name the variables
+// OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID, OIDC_<NAME>_CLIENT_SECRET, OIDC_<NAME>_REDIRECT_URL and
+// optionally OIDC_<NAME>_SCOPES (space separated, "openid email profile" by default).
+// Providers with missing settings are skipped.
+func Init() error {
+	reg := &Registry{providers: map[string]*Provider{}}
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		env := func(key string) string {
+			return strings.TrimSpace(os.Getenv("OIDC_" + strings.ToUpper(name) + "_" + key))
+		}
+		p := &Provider{
+			Name:         name,
+			Issuer:       strings.TrimRight(env("ISSUER"), "/"),
+			ClientID:     env("CLIENT_ID"),
+			ClientSecret: env("CLIENT_SECRET"),
+			RedirectURL:  env("REDIRECT_URL"),
+			Scopes:       strings.Fields(env("SCOPES")),
+		}
+		if p.Issuer == "" || p.ClientID == "" || p.RedirectURL == "" {
+			return fmt.Errorf("oidc provider %s is missing issuer, client id or redirect url", name)
+		}
+		if len(p.Scopes) == 0 {
+			p.Scopes = []string{"openid", "email", "profile"}
+		}
+		reg.providers[name] = p
+	}
+	Default = reg
+	return nil
+}
+
+func (r *Registry) Get(name string) (*Provider, error) {
+	p, ok := r.providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return p, nil
+}
+
+// Names lists the configured providers in a stable order
+func (r *Registry) Names() []string {
+	res := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		res = append(res, name)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// metadata returns the provider's discovery document, fetching it again once it is older than discoveryTTL
+func (p *Provider) metadata(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.meta != nil && time.Since(p.fetchedAt) < discoveryTTL {
+		return p.meta, nil
+	}
+
+	var d discovery
+	if err := getJSON(ctx, p.Issuer+"/.well-known/openid-configuration", &d); err != nil {
+		if p.meta != nil {
+			// keep using the last good document while the provider is unreachable
+			return p.meta, nil
+		}
+		return nil, fmt.Errorf("oidc discovery for %s: %w", p.Name, err)
+	}
+	if d.Issuer == "" || d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, fmt.Errorf("oidc discovery for %s: incomplete metadata", p.Name)
+	}
+	if !issuerMatches(d.Issuer, p.Issuer) {
+		return nil, fmt.Errorf("oidc discovery for %s: issuer %q does not match the configured %q", p.Name, d.Issuer, p.Issuer)
+	}
+	if p.jwks == nil || p.jwks.uri != d.JWKSURI {
+		p.jwks = newKeySet(d.JWKSURI)
+	}
+	p.meta, p.fetchedAt = &d, time.Now()
+	return p.meta, nil
+}
+
+// issuerMatches compares the discovered issuer with the configured one. Multi-tenant providers publish
+// a "{tenantid}" template (Microsoft's common endpoint), which matches any value in that path segment.
+func issuerMatches(discovered, configured string) bool {
+	discovered = strings.TrimRight(discovered, "/")
+	i := strings.Index(discovered, "{tenantid}")
+	if i < 0 {
+		return discovered == configured
+	}
+	prefix, suffix := discovered[:i], discovered[i+len("{tenantid}"):]
+	if !strings.HasPrefix(configured, prefix) || !strings.HasSuffix(configured, suffix) || len(configured) <= len(prefix)+len(suffix) {
+		return false
+	}
+	return !strings.Contains(configured[len(prefix):len(configured)-len(suffix)], "/")
+}
+
+// AuthCodeURL builds the authorization request for the code flow with PKCE (S256)
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeVerifier string) (string, error) {
+	meta, err := p.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.ClientID},
+		"redirect_uri":          {p.RedirectURL},
+		"scope":                 {strings.Join(p.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {CodeChallenge(codeVerifier)},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+func getJSON(ctx context.Context, u string, dst interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", u, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(dst)
+}
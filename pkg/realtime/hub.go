@@ -0,0 +1,141 @@
+package realtime
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog/log"
+)
+
+// Channel is the Postgres NOTIFY channel fired by the user_events trigger
+const Channel = "user_events"
+
+// eventRetention is how long stored events stay available for replay after a reconnect
+const eventRetention = 7 * 24 * time.Hour
+
+// Hub fans Postgres notifications out to the streams of connected users.
+// Subscribers only receive a wake-up signal; they read the actual events from user_events
+// starting at their own cursor, so a missed or coalesced signal never loses data.
+type Hub struct {
+	mu   sync.Mutex
+	subs map[uuid.UUID]map[chan struct{}]struct{}
+
+	done     chan struct{}
+	doneOnce sync.Once
+}
+
+var DefaultHub = NewHub()
+
+func NewHub() *Hub {
+	return &Hub{subs: map[uuid.UUID]map[chan struct{}]struct{}{}, done: make(chan struct{})}
+}
+
+// Done is closed once the context passed to Start is cancelled, i.e. when the server shuts down.
+// Long-lived streams select on it so they do not hold up a graceful shutdown.
+func (h *Hub) Done() <-chan struct{} {
+	return h.done
+}
+
+// Subscribe registers a stream for userID. The returned func must be called when the stream closes.
+func (h *Hub) Subscribe(userID uuid.UUID) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	h.mu.Lock()
+	if h.subs[userID] == nil {
+		h.subs[userID] = map[chan struct{}]struct{}{}
+	}
+	h.subs[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		delete(h.subs[userID], ch)
+		if len(h.subs[userID]) == 0 {
+			delete(h.subs, userID)
+		}
+		h.mu.Unlock()
+	}
+}
+
+func (h *Hub) wake(userID uuid.UUID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs[userID] {
+		signal(ch)
+	}
+}
+
+func (h *Hub) wakeAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, chans := range h.subs {
+		for ch := range chans {
+			signal(ch)
+		}
+	}
+}
+
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// Start listens on the user_events channel until ctx is cancelled and periodically prunes old events
+func (h *Hub) Start(ctx context.Context, connStr string, db *sql.DB) {
+	go func() {
+		<-ctx.Done()
+		h.doneOnce.Do(func() { close(h.done) })
+	}()
+
+	listener := pq.NewListener(connStr, 10*time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Error().Err(err).Msg("realtime listener error")
+		}
+	})
+	if err := listener.Listen(Channel); err != nil {
+		log.Error().Err(err).Msg("failed to listen for user events")
+		return
+	}
+
+	go func() {
+		defer listener.Close()
+		prune := time.NewTicker(time.Hour)
+		defer prune.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case n := <-listener.Notify:
+				if n == nil {
+					// connection was re-established; notifications may have been missed
+					h.wakeAll()
+					continue
+				}
+				var payload struct {
+					UserID uuid.UUID `json:"user_id"`
+				}
+				if err := json.Unmarshal([]byte(n.Extra), &payload); err != nil {
+					log.Error().Err(err).Msg("invalid user event payload")
+					continue
+				}
+				h.wake(payload.UserID)
+			case <-time.After(90 * time.Second):
+				if err := listener.Ping(); err != nil {
+					log.Error().Err(err).Msg("realtime listener ping failed")
+				}
+			case <-prune.C:
+				if _, err := db.Exec(`DELETE FROM user_events WHERE created_at < $1`, time.Now().Add(-eventRetention)); err != nil {
+					log.Error().Err(err).Msg("failed to prune user events")
+				}
+			}
+		}
+	}()
+	log.Info().Str("channel", Channel).Msg("realtime hub listening")
+}
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/gilanghuda/backend-Quizzo/app/controllers"
+	"github.com/gilanghuda/backend-Quizzo/pkg/middleware"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterAdminRoutes(app *fiber.App) {
+	admin := app.Group("/admin", middleware.JWTProtected(), middleware.RequireRole(utils.RoleAdmin))
+	admin.Get("/reports", controllers.GetReports)
+	admin.Get("/reports/:id", controllers.GetReport)
+	admin.Post("/reports/:id/resolve", controllers.ResolveReport)
+	admin.Get("/users", controllers.AdminListUsers)
+	admin.Put("/users/:id/role", controllers.AdminSetUserRole)
+	admin.Post("/users/:id/suspend", controllers.AdminSuspendUser)
+	admin.Post("/users/:id/unsuspend", controllers.AdminUnsuspendUser)
+	admin.Post("/users/:id/2fa/reset", controllers.AdminResetTwoFactor)
+	admin.Put("/settings/2fa", controllers.AdminSetTwoFactorRequirement)
+	admin.Delete("/content/:type/:id", controllers.AdminDeleteContent)
+}
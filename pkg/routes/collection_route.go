@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"github.com/gilanghuda/backend-Quizzo/app/controllers"
+	"github.com/gilanghuda/backend-Quizzo/pkg/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterCollectionRoutes(app *fiber.App) {
+	b := app.Group("/bookmarks", middleware.JWTProtected())
+	b.Get("/", controllers.GetBookmarks)
+	b.Post("/:quizId", controllers.AddBookmark)
+	b.Delete("/:quizId", controllers.RemoveBookmark)
+
+	col := app.Group("/collections", middleware.JWTProtected())
+	col.Post("/", controllers.CreateCollection)
+	col.Get("/mine", controllers.GetMyCollections)
+	col.Get("/shared/:token", controllers.GetSharedCollection)
+	col.Get("/runs/:runId", controllers.GetCollectionRun)
+	col.Get("/user/:userId", controllers.GetUserCollections)
+	col.Get("/:id", controllers.GetCollection)
+	col.Put("/:id", controllers.UpdateCollection)
+	col.Delete("/:id", controllers.DeleteCollection)
+	col.Post("/:id/items", controllers.AddCollectionItem)
+	col.Delete("/:id/items/:quizId", controllers.RemoveCollectionItem)
+	col.Put("/:id/order", controllers.ReorderCollection)
+	col.Post("/:id/share", controllers.ShareCollection)
+	col.Delete("/:id/share", controllers.UnshareCollection)
+	col.Post("/:id/start", controllers.StartCollection)
+}
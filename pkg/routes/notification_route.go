@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/gilanghuda/backend-Quizzo/app/controllers"
+	"github.com/gilanghuda/backend-Quizzo/pkg/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterNotificationRoutes(app *fiber.App) {
+	n := app.Group("/notifications", middleware.JWTProtected())
+	n.Get("/", controllers.GetNotifications)
+	n.Get("/unread-count", controllers.GetUnreadNotificationCount)
+	n.Post("/read-all", controllers.MarkAllNotificationsRead)
+	n.Get("/preferences", controllers.GetNotificationPreferences)
+	n.Put("/preferences", controllers.UpdateNotificationPreferences)
+	n.Post("/:id/read", controllers.MarkNotificationRead)
+}
@@ -3,25 +3,39 @@ package routes
 import (
 	"github.com/gilanghuda/backend-Quizzo/app/controllers"
 	"github.com/gilanghuda/backend-Quizzo/pkg/middleware"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
 	"github.com/gofiber/fiber/v2"
 )
 
 func RegisterQuizRoutes(app *fiber.App) {
+	quizRead := middleware.RequireScope(utils.ScopeQuizRead)
+	quizWrite := middleware.RequireScope(utils.ScopeQuizWrite)
+
 	app.Post("/quizzes", controllers.UploadAndGenerateQuiz)
 	app.Get("/quiz/leaderboard/users", controllers.GetUserLeaderboard)
 	app.Get("/quiz/leaderboard/study-groups", controllers.GetStudyGroupLeaderboard)
-	app.Get("/quizes/:id", controllers.GetQuizDetail)
+	app.Get("/quizes/:id", middleware.JWTOptional(), quizRead, controllers.GetQuizDetail)
+	app.Get("/quizes/:id/forks", controllers.GetQuizForks)
+	app.Get("/quiz/subjects", controllers.GetSubjects)
+	app.Get("/quiz/tags", controllers.GetPopularTags)
+	app.Get("/quiz/tags/:tag", controllers.GetQuizzesByTag)
+	app.Get("/quiz/subjects/:tag", controllers.GetQuizzesBySubject)
 
-	app.Get("/files/:id", controllers.GetQuizFile)
+	app.Get("/files/:id", middleware.JWTOptional(), quizRead, controllers.GetQuizFile)
 
 	quiz := app.Group("/quiz", middleware.JWTProtected())
-	quiz.Post("/upload", controllers.UploadAndGenerateQuiz)
-	quiz.Get("/getMyQuiz", controllers.GetQuizByUser)
-	quiz.Get("/feed", controllers.GetFeed)
+	quiz.Post("/upload", quizWrite, controllers.UploadAndGenerateQuiz)
+	quiz.Get("/getMyQuiz", quizRead, controllers.GetQuizByUser)
+	quiz.Get("/feed", quizRead, controllers.GetFeed)
 	quiz.Post("/attempt", controllers.AttemptQuiz)
-	quiz.Get("/attempts", controllers.GetAttemptHistory)
-	quiz.Get("/attempt/:id", controllers.GetAttemptDetail)
-	quiz.Post("/assign-to-study-group", controllers.AddQuizToStudyGroup)
+	quiz.Get("/attempts", quizRead, controllers.GetAttemptHistory)
+	quiz.Get("/attempt/:id", quizRead, controllers.GetAttemptDetail)
+	quiz.Post("/assign-to-study-group", quizWrite, controllers.AddQuizToStudyGroup)
+	quiz.Put("/:id/tags", quizWrite, controllers.UpdateQuizTags)
+	quiz.Put("/:id/visibility", quizWrite, controllers.UpdateQuizVisibility)
+	quiz.Post("/:id/share-token/rotate", quizWrite, controllers.RotateQuizShareToken)
+	quiz.Post("/:id/fork", quizWrite, controllers.ForkQuiz)
+	quiz.Put("/:id/forking", quizWrite, controllers.UpdateQuizForking)
 
-	app.Get("/study-groups/:id/quizzes", controllers.GetQuizzesByStudyGroup)
+	app.Get("/study-groups/:id/quizzes", middleware.JWTOptional(), quizRead, controllers.GetQuizzesByStudyGroup)
 }
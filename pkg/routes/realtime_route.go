@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/gilanghuda/backend-Quizzo/app/controllers"
+	"github.com/gilanghuda/backend-Quizzo/pkg/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterRealtimeRoutes(app *fiber.App) {
+	events := app.Group("/events", middleware.JWTProtected())
+	events.Get("/stream", controllers.StreamEvents)
+	events.Get("/", controllers.GetEvents)
+}
@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"github.com/gilanghuda/backend-Quizzo/app/controllers"
+	"github.com/gilanghuda/backend-Quizzo/pkg/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterReportRoutes(app *fiber.App) {
+	app.Post("/reports", middleware.JWTProtected(), controllers.CreateReport)
+}
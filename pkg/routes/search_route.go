@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"github.com/gilanghuda/backend-Quizzo/app/controllers"
+	"github.com/gilanghuda/backend-Quizzo/pkg/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+func RegisterSearchRoutes(app *fiber.App) {
+	app.Get("/search", middleware.JWTOptional(), controllers.Search)
+}
@@ -13,6 +13,9 @@ func RegisterSocialsRoutes(app *fiber.App) {
 	s := app.Group("/socials", middleware.JWTProtected())
 	s.Post("/like/:id", controllers.ToggleLike)
 	s.Post("/comment/:id", controllers.AddComment)
+	s.Put("/comment/:id", controllers.EditComment)
 	s.Delete("/comment/:id", controllers.DeleteComment)
+	s.Get("/comment/:id/history", controllers.GetCommentHistory)
+	s.Get("/comment/:id/replies", controllers.GetCommentReplies)
 	s.Get("/comments/:id", controllers.GetComments)
 }
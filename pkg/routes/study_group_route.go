@@ -3,21 +3,42 @@ package routes
 import (
 	"github.com/gilanghuda/backend-Quizzo/app/controllers"
 	"github.com/gilanghuda/backend-Quizzo/pkg/middleware"
+	"github.com/gilanghuda/backend-Quizzo/pkg/utils"
 	"github.com/gofiber/fiber/v2"
 )
 
 func RegisterStudyGroupRoutes(app *fiber.App) {
 	app.Get("/study-groups", controllers.GetAllStudyGroups)
+	app.Get("/study-groups/discover", controllers.DiscoverStudyGroups)
+
+	groupsManage := middleware.RequireScope(utils.ScopeGroupsManage)
 
 	studyGroup := app.Group("/study-group", middleware.JWTProtected())
-	studyGroup.Post("/create", controllers.CreateStudyGroup)
-	studyGroup.Get("/mines", controllers.GetUserStudyGroups)
+	studyGroup.Post("/create", groupsManage, controllers.CreateStudyGroup)
+	studyGroup.Get("/mines", groupsManage, controllers.GetUserStudyGroups)
 	studyGroup.Get("/get-all-studygroup", controllers.GetAllStudyGroups)
+	studyGroup.Get("/recommendations", controllers.RecommendStudyGroups)
 
 	studyGroup.Post("/join", controllers.JoinStudyGroup)
-	studyGroup.Get("/:id/detail", controllers.GetStudyGroupDetail)
-	studyGroup.Get("/:id", controllers.GetStudyGroup)
-	studyGroup.Put("/:id", controllers.UpdateStudyGroup)
-	studyGroup.Delete("/:id", controllers.DeleteStudyGroup)
+	studyGroup.Get("/:id/detail", groupsManage, controllers.GetStudyGroupDetail)
+	studyGroup.Get("/:id/analytics", middleware.RequireScope(utils.ScopeAnalyticsRead), controllers.GetStudyGroupAnalytics)
+	studyGroup.Get("/:id", groupsManage, controllers.GetStudyGroup)
+	studyGroup.Put("/:id", groupsManage, controllers.UpdateStudyGroup)
+	studyGroup.Delete("/:id", groupsManage, controllers.DeleteStudyGroup)
+
+	studyGroup.Post("/:id/invites", groupsManage, controllers.CreateStudyGroupInvite)
+	studyGroup.Get("/:id/invites", groupsManage, controllers.GetStudyGroupInvites)
+	studyGroup.Get("/:id/invites/audit", groupsManage, controllers.GetStudyGroupInviteAudit)
+	studyGroup.Delete("/:id/invites/:inviteId", groupsManage, controllers.RevokeStudyGroupInvite)
+	studyGroup.Post("/:id/invite-code/rotate", groupsManage, controllers.RotateStudyGroupInviteCode)
+	studyGroup.Put("/:id/members/:userId/role", groupsManage, controllers.SetStudyGroupMemberRole)
+
+	studyGroup.Get("/:id/threads", controllers.GetStudyGroupThreads)
+	studyGroup.Post("/:id/threads", controllers.CreateStudyGroupThread)
+	studyGroup.Get("/:id/threads/:threadId", controllers.GetStudyGroupThreadPosts)
+	studyGroup.Post("/:id/threads/:threadId/posts", controllers.CreateStudyGroupPost)
+	studyGroup.Put("/:id/threads/:threadId/pin", controllers.PinStudyGroupThread)
+	studyGroup.Delete("/:id/threads/:threadId", controllers.DeleteStudyGroupThread)
+	studyGroup.Get("/:id/quizzes/:quizId/discussion", controllers.GetQuizDiscussion)
 
 }
@@ -10,13 +10,51 @@ func RegisterUserRoutes(app *fiber.App) {
 	app.Post("/signup", controllers.UserSignUp)
 	app.Post("/signin", controllers.UserSignIn)
 	app.Post("/signin/google", controllers.UserSignInGoogle)
-	app.Post("/logout", controllers.UserLogout)
+	app.Post("/logout", middleware.JWTOptional(), controllers.UserLogout)
+	app.Post("/auth/refresh", controllers.RefreshSession)
+	app.Get("/avatars/:key", controllers.GetAvatar)
+	app.Post("/auth/verify-email", controllers.VerifyEmail)
+	app.Post("/auth/verify-email/resend", controllers.ResendVerificationEmail)
+	app.Post("/auth/forgot-password", controllers.ForgotPassword)
+	app.Post("/auth/reset-password", controllers.ResetPassword)
+	app.Post("/auth/2fa/verify", controllers.VerifyTwoFactorSignIn)
+	app.Get("/auth/oidc/providers", controllers.GetOIDCProviders)
+	app.Get("/auth/oidc/:provider/login", controllers.StartOIDCLogin)
+	app.Get("/auth/oidc/:provider/callback", middleware.JWTOptional(), controllers.OIDCCallback)
+	app.Post("/auth/oidc/:provider/callback", middleware.JWTOptional(), controllers.OIDCCallback)
 
 	user := app.Group("/user", middleware.JWTProtected())
 	user.Get("/profile", controllers.UserProfile)
+	user.Put("/profile", controllers.UpdateProfile)
+	user.Put("/password", controllers.ChangePassword)
+	user.Post("/avatar", controllers.UploadAvatar)
+	user.Delete("/avatar", controllers.DeleteAvatar)
+	user.Get("/export", controllers.ExportUserData)
+	user.Post("/delete", controllers.RequestAccountDeletion)
+	user.Post("/delete/cancel", controllers.CancelAccountDeletion)
 	user.Post("/follow/:id", controllers.FollowUser)
 	user.Post("/unfollow/:id", controllers.UnfollowUser)
+	user.Post("/block/:id", controllers.BlockUser)
+	user.Post("/unblock/:id", controllers.UnblockUser)
+	user.Get("/blocked", controllers.GetBlockedUsers)
 	user.Get("/recommendations", controllers.RecommendUsers)
+	user.Get("/sessions", controllers.GetSessions)
+	user.Post("/sessions/revoke-all", controllers.RevokeAllSessions)
+	user.Delete("/sessions/:id", controllers.RevokeSession)
+	user.Get("/2fa", controllers.GetTwoFactorStatus)
+	user.Post("/2fa/setup", controllers.SetupTwoFactor)
+	user.Post("/2fa/enable", controllers.EnableTwoFactor)
+	user.Post("/2fa/disable", controllers.DisableTwoFactor)
+	user.Post("/2fa/recovery-codes", controllers.RegenerateRecoveryCodes)
+	user.Get("/api-keys", controllers.GetAPIKeys)
+	user.Post("/api-keys", controllers.CreateAPIKey)
+	user.Delete("/api-keys/:id", controllers.RevokeAPIKey)
+	user.Get("/identities", controllers.GetIdentities)
+	user.Post("/identities/:provider/link", controllers.LinkOIDCIdentity)
+	user.Delete("/identities/:provider", controllers.UnlinkIdentity)
 
-	app.Get("/users/:id", controllers.GetUserByID)
+	users := app.Group("/users", middleware.JWTOptional())
+	users.Get("/:id", controllers.GetUserByID)
+	users.Get("/:id/followers", controllers.GetFollowers)
+	users.Get("/:id/following", controllers.GetFollowing)
 }
@@ -0,0 +1,13 @@
+package utils
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccountDeletionGracePeriod is how long a deletion request can still be cancelled
+const AccountDeletionGracePeriod = 14 * 24 * time.Hour
+
+// DeletedUserID owns the quizzes that were kept, anonymized, when their author deleted the account
+var DeletedUserID = uuid.MustParse("00000000-0000-0000-0000-000000000000")
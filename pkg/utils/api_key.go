@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	ScopeQuizRead      = "quiz:read"
+	ScopeQuizWrite     = "quiz:write"
+	ScopeGroupsManage  = "groups:manage"
+	ScopeAnalyticsRead = "analytics:read"
+
+	// APIKeyPrefix tells API keys apart from JWTs in the Authorization header
+	APIKeyPrefix = "qzk_"
+
+	apiKeyLength        = 40
+	apiKeyDisplayLength = 12
+
+	MaxAPIKeysPerUser = 20
+	// APIKeyLastUsedInterval limits how often the last-used columns are written for a busy key
+	APIKeyLastUsedInterval = time.Minute
+)
+
+var ValidScopes = []string{ScopeQuizRead, ScopeQuizWrite, ScopeGroupsManage, ScopeAnalyticsRead}
+
+func IsValidScope(scope string) bool {
+	for _, s := range ValidScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateAPIKey returns a new key, the prefix shown in listings and the hash that is stored
+func GenerateAPIKey() (string, string, string, error) {
+	secret, err := GenerateInviteCode(apiKeyLength)
+	if err != nil {
+		return "", "", "", err
+	}
+	key := APIKeyPrefix + secret
+	return key, key[:apiKeyDisplayLength], HashToken(key), nil
+}
+
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
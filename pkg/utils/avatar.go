@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+)
+
+const (
+	MaxAvatarBytes     = 5 * 1024 * 1024
+	maxAvatarDimension = 4096
+	minAvatarDimension = 32
+)
+
+// AvatarSizes are the square sizes every avatar is stored in, smallest first
+var AvatarSizes = []int{64, 128, 256}
+
+var ErrInvalidAvatar = errors.New("avatar must be a JPEG, PNG or GIF image")
+
+// DecodeAvatar checks the upload's size, type and dimensions before decoding it, so oversized
+// images are rejected without allocating their pixels
+func DecodeAvatar(data []byte) (image.Image, error) {
+	if len(data) > MaxAvatarBytes {
+		return nil, fmt.Errorf("avatar must be at most %d MB", MaxAvatarBytes/(1024*1024))
+	}
+	switch http.DetectContentType(data) {
+	case "image/jpeg", "image/png", "image/gif":
+	default:
+		return nil, ErrInvalidAvatar
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidAvatar
+	}
+	if cfg.Width > maxAvatarDimension || cfg.Height > maxAvatarDimension {
+		return nil, fmt.Errorf("avatar must be at most %dx%d pixels", maxAvatarDimension, maxAvatarDimension)
+	}
+	if cfg.Width < minAvatarDimension || cfg.Height < minAvatarDimension {
+		return nil, fmt.Errorf("avatar must be at least %dx%d pixels", minAvatarDimension, minAvatarDimension)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidAvatar
+	}
+	return img, nil
+}
+
+// ResizeSquare center-crops img to a square and scales it to size x size by averaging the source
+// pixels that fall into each target pixel. Transparent areas are composed onto white.
+func ResizeSquare(img image.Image, size int) *image.RGBA {
+	b := img.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	for dy := 0; dy < size; dy++ {
+		sy0 := y0 + dy*side/size
+		sy1 := y0 + (dy+1)*side/size
+		if sy1 <= sy0 {
+			sy1 = sy0 + 1
+		}
+		for dx := 0; dx < size; dx++ {
+			sx0 := x0 + dx*side/size
+			sx1 := x0 + (dx+1)*side/size
+			if sx1 <= sx0 {
+				sx1 = sx0 + 1
+			}
+			var r, g, bl, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					// blend onto white using premultiplied values
+					r += uint64(cr + (0xffff - ca))
+					g += uint64(cg + (0xffff - ca))
+					bl += uint64(cb + (0xffff - ca))
+					n++
+				}
+			}
+			dst.SetRGBA(dx, dy, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(bl / n >> 8),
+				A: 0xff,
+			})
+		}
+	}
+	return dst
+}
+
+func EncodeJPEG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// AvatarFileID is the storage id of one size of an avatar
+func AvatarFileID(key string, size int) string {
+	return fmt.Sprintf("avatar_%s_%d", key, size)
+}
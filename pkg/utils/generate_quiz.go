@@ -41,10 +41,14 @@ Aturan:
 - Buat %d soal pilihan ganda dengan tingkat kesulitan %s.
 - Untuk setiap soal sertakan "explanation" (pembahasan singkat) yang menjelaskan jawaban yang benar.
 - Judul ("title") harus dihasilkan secara otomatis berdasarkan isi materi.
+- "subject" harus salah satu dari: %s.
+- "tags" berisi 3 sampai 5 kata kunci topik materi, huruf kecil.
 - JANGAN sertakan teks pengantar, penjelasan, atau markdown format seperti %s.
 - Strukturnya harus seperti ini:
 {
   "title": "Judul yang relevan dengan materi",
+  "subject": "biology",
+  "tags": ["kata kunci", "topik"],
   "questions": [
     {
       "question": "Isi pertanyaan...",
@@ -54,7 +58,7 @@ Aturan:
     }
   ]
 }
-`, question_count, difficulty, "```json")
+`, question_count, difficulty, strings.Join(Subjects, ", "), "```json")
 
 	payload := map[string]interface{}{
 		"contents": []map[string]interface{}{
@@ -112,7 +116,9 @@ Aturan:
 	clean = strings.TrimSpace(clean)
 
 	var aiResp struct {
-		Title     string `json:"title"`
+		Title     string   `json:"title"`
+		Subject   string   `json:"subject"`
+		Tags      []string `json:"tags"`
 		Questions []struct {
 			Question      string   `json:"question"`
 			Options       []string `json:"options"`
@@ -128,9 +134,17 @@ Aturan:
 		}, nil
 	}
 
+	subject := strings.ToLower(strings.TrimSpace(aiResp.Subject))
+	if !IsValidSubject(subject) {
+		subject = SubjectOther
+	}
+	tags := CleanQuizTags(aiResp.Tags)
+
 	quiz := models.Quiz{
 		Title:      aiResp.Title,
 		Difficulty: difficulty,
+		Subject:    subject,
+		Tags:       tags,
 		Questions:  []models.Question{},
 	}
 
@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9_.]{1,50})`)
+
+// ExtractMentions returns the distinct usernames referenced as @username in content, in order of appearance
+func ExtractMentions(content string) []string {
+	res := []string{}
+	seen := map[string]bool{}
+	for _, m := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		// "thanks @alice." ends the sentence, the dot is not part of the name
+		name := strings.TrimRight(m[1], ".")
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		res = append(res, name)
+	}
+	return res
+}
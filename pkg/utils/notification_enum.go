@@ -0,0 +1,32 @@
+package utils
+
+const (
+	NotificationFollow       = "follow"
+	NotificationLike         = "like"
+	NotificationComment      = "comment"
+	NotificationGroupJoin    = "group_join"
+	NotificationQuizAssigned = "quiz_assigned"
+	NotificationMention      = "mention"
+	NotificationReply        = "reply"
+	NotificationQuizForked   = "quiz_forked"
+)
+
+var ValidNotificationTypes = []string{
+	NotificationFollow,
+	NotificationLike,
+	NotificationComment,
+	NotificationGroupJoin,
+	NotificationQuizAssigned,
+	NotificationMention,
+	NotificationReply,
+	NotificationQuizForked,
+}
+
+func IsValidNotificationType(t string) bool {
+	for _, v := range ValidNotificationTypes {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,6 @@
+package utils
+
+import "time"
+
+// OIDCLoginStateTTL is how long a user has to finish signing in at the provider
+const OIDCLoginStateTTL = 10 * time.Minute
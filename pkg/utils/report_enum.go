@@ -0,0 +1,34 @@
+package utils
+
+const (
+	ReportTargetQuiz       = "quiz"
+	ReportTargetComment    = "comment"
+	ReportTargetUser       = "user"
+	ReportTargetStudyGroup = "study_group"
+)
+
+var ValidReportTargets = []string{ReportTargetQuiz, ReportTargetComment, ReportTargetUser, ReportTargetStudyGroup}
+
+const (
+	ReportStatusOpen      = "open"
+	ReportStatusDismissed = "dismissed"
+	ReportStatusActioned  = "actioned"
+)
+
+const (
+	ModerationDismiss     = "dismiss"
+	ModerationHideContent = "hide_content"
+	ModerationSuspendUser = "suspend_user"
+
+	// ModerationDeleteContent is recorded on reports closed by an admin deleting the content outright
+	ModerationDeleteContent = "delete_content"
+)
+
+func IsValidReportTarget(t string) bool {
+	for _, v := range ValidReportTargets {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
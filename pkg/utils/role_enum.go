@@ -6,3 +6,18 @@ const (
 )
 
 var ValidRoles = []string{RoleAdmin, RoleUser}
+
+const (
+	GroupRoleOwner     = "owner"
+	GroupRoleModerator = "moderator"
+	GroupRoleMember    = "member"
+)
+
+func IsValidRole(role string) bool {
+	for _, r := range ValidRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
@@ -12,16 +12,21 @@ import (
 )
 
 func SaveFile(idFile, filename string, data []byte) error {
+	return SaveFileWithType(idFile, filename, "application/pdf", data)
+}
+
+// SaveFileWithType uploads data to the file storage under idFile with an explicit content type
+func SaveFileWithType(idFile, filename, contentType string, data []byte) error {
 	var b bytes.Buffer
 	writer := multipart.NewWriter(&b)
 	if err := writer.WriteField("id_file", idFile); err != nil {
 		return err
 	}
 
-	// Create form file part with explicit Content-Type header set to application/pdf
+	// Create form file part with explicit Content-Type header
 	head := textproto.MIMEHeader{}
 	head.Set("Content-Disposition", fmt.Sprintf("form-data; name=\"file\"; filename=\"%s\"", filename))
-	head.Set("Content-Type", "application/pdf")
+	head.Set("Content-Type", contentType)
 
 	part, err := writer.CreatePart(head)
 	if err != nil {
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
+
+	refreshTokenLength = 48
+)
+
+// GenerateAccessToken signs a short-lived HS256 token bound to a session. JWTProtected rejects it
+// as soon as the session is revoked, even before it expires.
+func GenerateAccessToken(userID uuid.UUID, email, role string, sessionID uuid.UUID) (string, time.Time, error) {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return "", time.Time{}, errors.New("JWT secret not set")
+	}
+	now := time.Now()
+	exp := now.Add(AccessTokenTTL)
+	claims := jwt.MapClaims{
+		"user_id":   userID.String(),
+		"email":     email,
+		"user_role": role,
+		"sid":       sessionID.String(),
+		"iat":       now.Unix(),
+		"exp":       exp.Unix(),
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, exp, nil
+}
+
+// GenerateRefreshToken returns a new opaque refresh token and the hash that is stored for it
+func GenerateRefreshToken() (string, string, error) {
+	token, err := GenerateInviteCode(refreshTokenLength)
+	if err != nil {
+		return "", "", err
+	}
+	return token, HashToken(token), nil
+}
+
+// HashToken is used for every secret token kept server-side so a database leak does not leak sessions
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ExtractSessionID returns the session the access token was issued for
+func ExtractSessionID(c *fiber.Ctx) (uuid.UUID, error) {
+	claims := c.Locals("user")
+	var mapClaims map[string]interface{}
+
+	switch v := claims.(type) {
+	case map[string]interface{}:
+		mapClaims = v
+	case jwt.MapClaims:
+		mapClaims = map[string]interface{}(v)
+	default:
+		return uuid.Nil, errors.New("invalid token claims")
+	}
+
+	sid, ok := mapClaims["sid"].(string)
+	if !ok {
+		return uuid.Nil, errors.New("token is not bound to a session")
+	}
+	return uuid.Parse(sid)
+}
@@ -0,0 +1,50 @@
+package utils
+
+const SubjectOther = "other"
+
+// Subjects is the fixed top-level taxonomy for quizzes; free-form tags refine it
+var Subjects = []string{
+	"biology",
+	"chemistry",
+	"physics",
+	"mathematics",
+	"computer_science",
+	"history",
+	"geography",
+	"economics",
+	"language",
+	"literature",
+	"social_studies",
+	"religion",
+	"arts",
+	SubjectOther,
+}
+
+const (
+	MaxQuizTags   = 10
+	MaxQuizTagLen = 30
+)
+
+func IsValidSubject(s string) bool {
+	for _, v := range Subjects {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// CleanQuizTags normalizes generated tags, dropping ones that are too long and keeping at most MaxQuizTags
+func CleanQuizTags(tags []string) []string {
+	res := make([]string, 0, MaxQuizTags)
+	for _, t := range NormalizeTags(tags) {
+		if len(t) > MaxQuizTagLen {
+			continue
+		}
+		res = append(res, t)
+		if len(res) == MaxQuizTags {
+			break
+		}
+	}
+	return res
+}
@@ -0,0 +1,18 @@
+package utils
+
+import "strings"
+
+// NormalizeTags lowercases, trims and de-duplicates tags, dropping empty ones. It never returns nil.
+func NormalizeTags(tags []string) []string {
+	res := make([]string, 0, len(tags))
+	seen := map[string]bool{}
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		res = append(res, t)
+	}
+	return res
+}
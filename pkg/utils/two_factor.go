@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// TwoFactorChallengeTTL is how long a user has to enter the code after the password step
+	TwoFactorChallengeTTL = 5 * time.Minute
+	// TwoFactorMaxFailures wrong codes in a row lock the account's second factor for TwoFactorLockout,
+	// no matter how many challenges they were spread over
+	TwoFactorMaxFailures = 5
+	TwoFactorLockout     = 15 * time.Minute
+
+	RecoveryCodeCount = 10
+
+	twoFactorChallengeType = "2fa_challenge"
+
+	// SettingAdminRequire2FA makes two-factor authentication mandatory for the admin role
+	SettingAdminRequire2FA = "admin_require_2fa"
+)
+
+var ErrInvalidChallenge = errors.New("invalid or expired challenge token")
+
+// GenerateTwoFactorChallenge signs the token handed out after a correct password when the account has 2FA.
+// It has no session, so JWTProtected never accepts it as an access token.
+func GenerateTwoFactorChallenge(userID uuid.UUID) (string, string, time.Time, error) {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return "", "", time.Time{}, errors.New("JWT secret not set")
+	}
+	now := time.Now()
+	exp := now.Add(TwoFactorChallengeTTL)
+	jti := uuid.NewString()
+	claims := jwt.MapClaims{
+		"user_id": userID.String(),
+		"typ":     twoFactorChallengeType,
+		"jti":     jti,
+		"iat":     now.Unix(),
+		"exp":     exp.Unix(),
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	return signed, jti, exp, nil
+}
+
+// ParseTwoFactorChallenge returns the user and challenge id of a valid challenge token
+func ParseTwoFactorChallenge(token string) (uuid.UUID, uuid.UUID, error) {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return uuid.Nil, uuid.Nil, errors.New("JWT secret not set")
+	}
+	claims := jwt.MapClaims{}
+	parsed, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{"HS256"}), jwt.WithExpirationRequired())
+	if err != nil || !parsed.Valid {
+		return uuid.Nil, uuid.Nil, ErrInvalidChallenge
+	}
+	if typ, _ := claims["typ"].(string); typ != twoFactorChallengeType {
+		return uuid.Nil, uuid.Nil, ErrInvalidChallenge
+	}
+	jtiStr, _ := claims["jti"].(string)
+	jti, err := uuid.Parse(jtiStr)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, ErrInvalidChallenge
+	}
+	uid, _ := claims["user_id"].(string)
+	userID, err := uuid.Parse(uid)
+	if err != nil {
+		return uuid.Nil, uuid.Nil, ErrInvalidChallenge
+	}
+	return userID, jti, nil
+}
+
+// GenerateRecoveryCodes returns single-use codes formatted as xxxxx-xxxxx
+func GenerateRecoveryCodes() ([]string, error) {
+	codes := make([]string, RecoveryCodeCount)
+	for i := range codes {
+		c, err := GenerateInviteCode(10)
+		if err != nil {
+			return nil, err
+		}
+		c = strings.ToLower(c)
+		codes[i] = c[:5] + "-" + c[5:]
+	}
+	return codes, nil
+}
+
+// NormalizeRecoveryCode makes the hash independent of case, spaces and the dash
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, " ", "")
+	return strings.ReplaceAll(code, "-", "")
+}
+
+// secretKey derives the AES key for secrets that must be readable again, such as TOTP seeds.
+// TOTP_ENCRYPTION_KEY should be set so that rotating JWT_SECRET does not break enrolled authenticators.
+func secretKey() ([]byte, error) {
+	key := os.Getenv("TOTP_ENCRYPTION_KEY")
+	if key == "" {
+		key = os.Getenv("JWT_SECRET")
+	}
+	if key == "" {
+		return nil, errors.New("encryption key not set")
+	}
+	sum := sha256.Sum256([]byte("quizzo-secret:" + key))
+	return sum[:], nil
+}
+
+// EncryptSecret seals plaintext with AES-GCM; the nonce is prepended to the ciphertext
+func EncryptSecret(plaintext string) (string, error) {
+	key, err := secretKey()
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func DecryptSecret(encoded string) (string, error) {
+	key, err := secretKey()
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("ciphertext too short")
+	}
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,16 @@
+package utils
+
+import "time"
+
+const (
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposePasswordReset     = "password_reset"
+)
+
+const (
+	EmailVerificationTTL = 24 * time.Hour
+	PasswordResetTTL     = time.Hour
+
+	// UserTokenResendInterval is the minimum time between two emails of the same kind to one user
+	UserTokenResendInterval = time.Minute
+)
@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"errors"
+	"regexp"
+	"unicode/utf8"
+)
+
+const (
+	UsernameMinLength = 3
+	UsernameMaxLength = 30
+)
+
+// usernamePattern matches the characters that @mentions recognise, so every username can be mentioned
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9_.]+$`)
+
+var ErrInvalidUsername = errors.New("username must be 3 to 30 characters of letters, digits, '_' and '.'")
+
+// ValidateUsername is shared by sign-up and profile editing so both accept the same names
+func ValidateUsername(name string) error {
+	n := utf8.RuneCountInString(name)
+	if n < UsernameMinLength || n > UsernameMaxLength || !usernamePattern.MatchString(name) {
+		return ErrInvalidUsername
+	}
+	return nil
+}
+
+// SanitizeUsername turns a display name or email local part into a valid username base by dropping
+// every character outside the username alphabet and truncating to maxLen runes. The result may be
+// shorter than UsernameMinLength.
+func SanitizeUsername(s string, maxLen int) string {
+	res := make([]rune, 0, len(s))
+	for _, r := range s {
+		if len(res) == maxLen {
+			break
+		}
+		if r < utf8.RuneSelf && usernamePattern.MatchString(string(r)) {
+			res = append(res, r)
+		}
+	}
+	return string(res)
+}
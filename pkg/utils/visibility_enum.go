@@ -0,0 +1,19 @@
+package utils
+
+const (
+	VisibilityPublic    = "public"
+	VisibilityUnlisted  = "unlisted"
+	VisibilityFollowers = "followers"
+	VisibilityGroup     = "group"
+)
+
+var ValidVisibilities = []string{VisibilityPublic, VisibilityUnlisted, VisibilityFollowers, VisibilityGroup}
+
+func IsValidVisibility(v string) bool {
+	for _, s := range ValidVisibilities {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}